@@ -0,0 +1,43 @@
+// Command comgraph renders a registry wiring document as a Graphviz digraph.
+//
+// Applications can dump their wiring with objects.Registry.Wiring and
+// WriteJSON, which is useful to diff between builds. This command turns that
+// JSON into DOT for viewing:
+//
+//	comgraph wiring.json | dot -Tsvg > wiring.svg
+//
+// If no file is given, the wiring is read from stdin.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gliderlabs/com/objects"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "comgraph:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	in := stdin
+	if len(args) > 0 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var wiring objects.Wiring
+	if err := json.NewDecoder(in).Decode(&wiring); err != nil {
+		return err
+	}
+	return wiring.WriteDOT(stdout)
+}
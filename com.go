@@ -16,17 +16,36 @@
 // In the above example component, it has fields with all three possible struct
 // tags:
 //
-// Singleton will pick the first object in the registry that implements that
-// interface. You can also use pointers to concrete types, for example to other
-// component types.
+// Singleton will pick the only enabled object in the registry that implements
+// that interface, returning an error if there is more than one. You can also
+// use pointers to concrete types, for example to other component types. When
+// several objects implement the interface, qualify the field with the name of
+// the object to use, as in `com:"singleton,name=postgres"`, or its exact FQN
+// with the fqn option.
 //
 // Extpoint is going to be a slice of all objects in the registry that implement
-// that interface.
+// that interface. It is sorted by priority, set with the Priority field of an
+// Object or by implementing objects.Prioritizer, and then by FQN. An extpoint
+// can also be a map with string keys, which is populated with the objects
+// keyed by name, or by FQN with `com:"extpoint,key=fqn"`.
 //
 // Config is not populated, but is allowed to be populated via the registry API.
 // If you're using the config package, it will do this for you and populate it
 // based on configuration. In this case, the key would be "DB" and the value
 // could be the name of any registered component that implements api.Store.
+//
+// Tagged fields of embedded structs are populated as well, so common fields
+// can be shared with a base struct. Fields of other struct or struct pointer
+// fields are included by tagging them `com:"nested"`. These fields are named
+// by their path, for example "Base.Log", including for config.
+//
+// Tags can have comma separated options after the kind. Singleton and config
+// fields with the required option, as in `com:"singleton,required"`, cause
+// Reload to return an error naming every such field left unassigned.
+//
+// Objects implementing objects.Decorator wrap the objects injected into
+// singleton and extpoint fields of the interface they decorate, for example
+// to add metrics to every api.Store without changing the components using it.
 package com
 
 import "github.com/gliderlabs/com/objects"
@@ -40,3 +59,16 @@ var (
 func Register(obj interface{}, name string) error {
 	return DefaultRegistry.Register(objects.New(obj, name))
 }
+
+// RegisterFactory will add an object with optional name to the default
+// registry that is constructed by a function when it is first needed.
+func RegisterFactory(fn interface{}, name string) error {
+	return DefaultRegistry.RegisterFactory(fn, name)
+}
+
+// RegisterConstructor will add an object with optional name to the default
+// registry that is returned by a constructor function with parameters
+// resolved from the registry.
+func RegisterConstructor(fn interface{}, name string) error {
+	return DefaultRegistry.RegisterConstructor(fn, name)
+}
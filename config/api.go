@@ -41,8 +41,23 @@ type Provider interface {
 
 // Initializer is an extension point interface with a hook allowing objects to
 // handle their configuration when configuration is loaded by the provider.
+//
+// Lazy objects registered with Registry.RegisterFactory are constructed when
+// configuration is loaded if it has a section for them. Otherwise they have
+// no value to call the hook on until they are constructed, so it is called by
+// the first Watcher reload after that instead. Factories of lazy objects that
+// are never reloaded should get any defaults they need themselves.
 type Initializer interface {
 	// InitializeConfig is called on a registered object with Settings for that
 	// object when configuration has been loaded.
 	InitializeConfig(config Settings) error
 }
+
+// Reconfigurer is an extension point interface for objects that can apply
+// configuration changes while running, as when configuration is reloaded by a
+// Watcher.
+type Reconfigurer interface {
+	// ReconfigureConfig is called on a registered object with its Settings
+	// from before and after configuration was reloaded, only if they changed.
+	ReconfigureConfig(old, new Settings) error
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/gliderlabs/com/objects"
@@ -18,47 +19,163 @@ const (
 // each object to any object that implements the Initializer interface. Then it
 // will lookup any struct fields with `com:"config"` and use the settings for
 // that object to get the name of an object from the registry to assign to that
-// field. It also disables any objects in the Registry referenced in the top-level
-// config section called "disabled".
-func Load(registry *objects.Registry, provider Provider, name string, paths []string) error {
-	// add extra paths from environment
+// field. Similarly, `com:"extpoint"` fields can be set to a list of object
+// names to order those objects first in the extension point. It also disables
+// any objects in the Registry referenced in the top-level config section
+// called "disabled", where glob patterns can be used to disable groups of
+// objects as with Registry.SetEnabledMatching.
+//
+// Paths are given from lowest to highest precedence, for example system, then
+// user paths. Paths from the NAME_CONFIG environment variable, where NAME is
+// the upper cased name, are added after them, followed by any paths given with
+// the LocalOverride option. So with providers that merge config files, like
+// the Viper provider, files the environment points to override all but local
+// override files.
+//
+// Top-level keys that don't match a registered object are ignored unless the
+// Strict or OnUnknownKey options are used to catch typos.
+//
+// Before any objects are initialized, the settings of enabled objects
+// implementing Schemer, or with fields tagged with config rules, are validated
+// as with Validate. Problems for all objects are returned together.
+//
+// If loading fails, the registry is restored to how it was before, though
+// changes objects made to themselves in InitializeConfig are not undone.
+func Load(registry *objects.Registry, provider Provider, name string, paths []string, opts ...Option) error {
+	o := newOptions(opts)
+	_, err := load(registry, provider, name, withEnvPaths(name, paths, o.local), nil, o)
+	return err
+}
+
+// withEnvPaths adds extra paths from the environment between paths and local
+// override paths.
+func withEnvPaths(name string, paths, local []string) []string {
 	envConfig := os.Getenv(fmt.Sprintf(envFormatter, strings.ToUpper(name)))
-	paths = append(paths, strings.Split(envConfig, ":")...)
+	merged := append(append([]string{}, paths...), strings.Split(envConfig, ":")...)
+	return append(merged, local...)
+}
+
+// loaded is what the load pipeline applied to a registry, which is passed to
+// the next load when reloading.
+type loaded struct {
+	// settings are the Settings of each object by FQN
+	settings map[string]Settings
+	// disabled are the FQNs of objects disabled by the disabled section that
+	// were enabled before
+	disabled []string
+}
+
+// load runs the load pipeline. When reloading, what the previous load applied
+// is passed, so only objects with changed Settings are reconfigured and
+// objects no longer disabled by config are enabled again.
+func load(registry *objects.Registry, provider Provider, name string, paths []string, previous *loaded, opts *options) (*loaded, error) {
+	if previous == nil {
+		previous = &loaded{}
+	}
 
 	// tell provider to load config
 	cfg, err := provider.Load(name, paths)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// get all top level keys in config
 	var keys map[string]interface{}
 	if err := cfg.Unmarshal(&keys); err != nil {
-		return err
+		return nil, err
+	}
+
+	// apply config, restoring the registry if any step fails
+	saved := registry.Snapshot()
+	applied, err := apply(registry, provider, cfg, keys, previous, opts)
+	if err != nil {
+		registry.Restore(saved)
+		return nil, err
+	}
+	return applied, nil
+}
+
+// apply applies loaded config to the objects of a registry.
+func apply(registry *objects.Registry, provider Provider, cfg Settings, keys map[string]interface{}, previous *loaded, opts *options) (*loaded, error) {
+	// disable objects first so matching sections doesn't construct them
+	disabled, err := applyDisabled(registry, cfg, previous.disabled)
+	if err != nil {
+		return nil, err
 	}
 
-	// iterate over all objects in registry
+	// match top level keys to objects, preferring names over aliases
+	sections := make(map[string]string)
+	var unknown []string
+	for key := range keys {
+		o, err := registry.Lookup(key)
+		if err == objects.ErrNotFound {
+			unknown = append(unknown, key)
+		}
+		if err != nil {
+			continue
+		}
+		if _, exists := sections[o.FQN()]; !exists || !isAlias(o, key) {
+			sections[o.FQN()] = key
+		}
+	}
+	if err := opts.reportUnknown(registry, unknown); err != nil {
+		return nil, err
+	}
+
+	// get settings for all objects, validating the ones that are new or
+	// changed against their schema before any are used
+	current := make(map[string]Settings)
+	var changed []*objects.Object
+	var errs objects.Errors
 	for _, obj := range registry.Objects() {
 		s := provider.New()
+		key, ok := sections[obj.FQN()]
+		if ok {
+			s = cfg.Sub(key)
+		} else {
+			key = strings.ToLower(obj.Name)
+		}
+		// lazy objects not constructed yet are left for a later load to
+		// initialize once they are
+		if obj.Value == nil {
+			continue
+		}
+		current[obj.FQN()] = s
 
-		// check if any top level key matches object
-		for key := range keys {
-			o, _ := registry.Lookup(key)
-			if o == obj {
-				s = cfg.Sub(key)
-				break
-			}
+		// leave objects alone if their settings haven't changed
+		if old := previous.settings[obj.FQN()]; old != nil && equalSettings(old, s) {
+			continue
 		}
+		changed = append(changed, obj)
+		if obj.Enabled {
+			errs = append(errs, validateSchema(s, schemaOf(obj.Value), key)...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
 
-		// if object is config.Initializer, initialize it
-		if init, ok := obj.Value.(Initializer); ok {
-			if err := init.InitializeConfig(s); err != nil {
-				return err
+	for _, obj := range changed {
+		s := current[obj.FQN()]
+		old := previous.settings[obj.FQN()]
+		if old == nil {
+			// if object is config.Initializer, initialize it
+			if init, ok := obj.Value.(Initializer); ok {
+				if err := init.InitializeConfig(s); err != nil {
+					return nil, err
+				}
+			}
+		} else if r, ok := obj.Value.(Reconfigurer); ok {
+			if err := r.ReconfigureConfig(old, s); err != nil {
+				return nil, err
 			}
 		}
 
 		// use config to lookup and set config fields
 		for name, field := range obj.Fields {
+			if field.Config && old != nil && !reflect.DeepEqual(old.Get(name), s.Get(name)) {
+				registry.Unassign(obj, name)
+			}
 			if field.Config && s.IsSet(name) {
 				objName, ok := s.Get(name).(string)
 				if !ok {
@@ -66,26 +183,103 @@ func Load(registry *objects.Registry, provider Provider, name string, paths []st
 				}
 				o, err := registry.Lookup(objName)
 				if err != nil {
-					return err
+					return nil, err
 				}
-				obj.Assign(name, o)
+				registry.Assign(obj, name, o)
+			}
+			if field.Extpoint && s.IsSet(name) {
+				registry.SetOrder(obj, name, toStrings(s.Get(name)))
 			}
 		}
 	}
 
-	// disable any objects found under disabled key
-	if cfg.IsSet(disabledKey) {
-		var disabled map[string]bool
-		cfg.UnmarshalKey(disabledKey, &disabled)
-		for name, d := range disabled {
-			o, err := registry.Lookup(name)
-			if err != nil {
-				continue
-			}
-			registry.SetEnabled(o.FQN(), !d)
+	// reload registry
+	if err := registry.Reload(); err != nil {
+		return nil, err
+	}
+	return &loaded{settings: current, disabled: disabled}, nil
+}
+
+// applyDisabled disables any objects found under the disabled key, after
+// enabling the objects a previous load disabled again. It returns the FQNs of
+// objects it disabled that were enabled.
+func applyDisabled(registry *objects.Registry, cfg Settings, previous []string) ([]string, error) {
+	for _, fqn := range previous {
+		registry.SetEnabled(fqn, true)
+	}
+	if !cfg.IsSet(disabledKey) {
+		return nil, nil
+	}
+	enabled := registry.Enabled()
+	var disabled map[string]bool
+	cfg.UnmarshalKey(disabledKey, &disabled)
+	// patterns go first so objects named exactly can override them
+	for name, d := range disabled {
+		if !objects.IsPattern(name) {
+			continue
+		}
+		if err := registry.SetEnabledMatching(name, !d); err != nil {
+			return nil, err
+		}
+	}
+	for name, d := range disabled {
+		if objects.IsPattern(name) {
+			continue
+		}
+		o, err := registry.Lookup(name)
+		if err != nil {
+			continue
+		}
+		registry.SetEnabled(o.FQN(), !d)
+	}
+	still := make(map[string]bool)
+	for _, o := range registry.Enabled() {
+		still[o.FQN()] = true
+	}
+	var fqns []string
+	for _, o := range enabled {
+		if !still[o.FQN()] {
+			fqns = append(fqns, o.FQN())
 		}
 	}
+	return fqns, nil
+}
 
-	// reload registry
-	return registry.Reload()
+// equalSettings returns true if two Settings have the same values.
+func equalSettings(a, b Settings) bool {
+	var am, bm map[string]interface{}
+	if a.Unmarshal(&am) != nil || b.Unmarshal(&bm) != nil {
+		return false
+	}
+	return reflect.DeepEqual(am, bm)
+}
+
+// isAlias returns true if a key is one of the aliases of an object.
+func isAlias(obj *objects.Object, key string) bool {
+	for _, alias := range obj.Aliases {
+		if strings.EqualFold(alias, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// toStrings converts a list from config into a string slice. A single string,
+// as it would be from environment, is treated as a comma separated list.
+func toStrings(v interface{}) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case string:
+		return strings.Split(vv, ",")
+	case []interface{}:
+		var s []string
+		for _, e := range vv {
+			if str, ok := e.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
 }
@@ -43,7 +43,7 @@ func newTestProvider(t *testing.T, path, config string) config.Provider {
 	}
 	v := viperlib.New()
 	v.SetFs(fs)
-	return &viper.Provider{v}
+	return &viper.Provider{Viper: v}
 }
 
 func fatal(t *testing.T, err error) {
@@ -162,6 +162,25 @@ Stringer = "Fooer"
 	}
 }
 
+func TestExtpointOrder(t *testing.T) {
+	var c struct {
+		Stringers []fmt.Stringer `com:"extpoint"`
+	}
+	reg := &objects.Registry{}
+	reg.Register(&objects.Object{Value: &c, Name: "Component"})
+	reg.Register(&objects.Object{Value: &stringer{"Foo"}, Name: "Fooer"})
+	reg.Register(&objects.Object{Value: &stringer{"Bar"}, Name: "Barer"})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[Component]
+Stringers = ["Fooer", "Barer"]
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	fatal(t, err)
+	if got := c.Stringers[0].String(); got != "Foo" {
+		t.Fatalf("got %#v; want %#v", got, "Foo")
+	}
+}
+
 func TestConfigFieldNoObject(t *testing.T) {
 	var c struct {
 		Stringer fmt.Stringer `com:"config"`
@@ -178,6 +197,46 @@ Stringer = "Fooer"
 	}
 }
 
+func TestLoadErrorRestores(t *testing.T) {
+	var c struct {
+		Stringer fmt.Stringer `com:"config"`
+	}
+	reg := &objects.Registry{}
+	reg.Register(&objects.Object{Value: &c, Name: "Component"})
+	reg.Register(&objects.Object{Value: &stringer{"Foo"}, Name: "Fooer"})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[Component]
+Stringer = "Missing"
+
+[disabled]
+Fooer = true
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	obj, err := reg.Lookup("Fooer")
+	fatal(t, err)
+	if !obj.Enabled {
+		t.Fatal("registry not restored after failed load")
+	}
+}
+
+func TestConfigFieldRequired(t *testing.T) {
+	var c struct {
+		Stringer fmt.Stringer `com:"config,required"`
+	}
+	reg := &objects.Registry{}
+	reg.Register(&objects.Object{Value: &c, Name: "Component"})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[Component]
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	if _, ok := err.(objects.Errors); !ok {
+		t.Fatalf("got %#v; want required field errors", err)
+	}
+}
+
 func TestDisabled(t *testing.T) {
 	reg := &objects.Registry{}
 	obj := &objects.Object{Value: &TestComponent{}}
@@ -197,6 +256,158 @@ IgnoreMe = true
 	}
 }
 
+func TestDisabledPattern(t *testing.T) {
+	reg := &objects.Registry{}
+	handler := &objects.Object{Value: &TestComponent{}, Name: "FooHandler"}
+	other := &objects.Object{Value: &TestComponent{}, Name: "BarHandler"}
+	reg.Register(handler, other)
+	provider := newTestProvider(t, "/etc/test.toml", `
+[disabled]
+"*Handler" = true
+BarHandler = false
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	fatal(t, err)
+	if handler.Enabled || !other.Enabled {
+		t.Fatal("pattern not applied before exact names")
+	}
+}
+
+func TestDisabledNotConstructed(t *testing.T) {
+	reg := &objects.Registry{}
+	calls := 0
+	err := reg.RegisterFactory(func() *TestComponent {
+		calls++
+		return &TestComponent{}
+	}, "LazyComponent")
+	fatal(t, err)
+	reg.SetEnabled(reg.Objects()[0].FQN(), false)
+	provider := newTestProvider(t, "/etc/test.toml", `
+[LazyComponent]
+foo = "foobar"
+`)
+	err = config.Load(reg, provider, "test", []string{"/etc"})
+	fatal(t, err)
+	if calls != 0 {
+		t.Fatal("disabled factory object constructed")
+	}
+}
+
+func TestStrictUnknownKeys(t *testing.T) {
+	reg := &objects.Registry{}
+	reg.Register(&objects.Object{Value: &TestComponent{}})
+	content := `
+[TestComponnet]
+foo = "foobar"
+
+[Unrelated]
+foo = "foobar"
+
+[disabled]
+TestComponent = false
+`
+	var warned []string
+	err := config.Load(reg, newTestProvider(t, "/etc/test.toml", content), "test", []string{"/etc"},
+		config.OnUnknownKey(func(err *config.UnknownKeyError) {
+			warned = append(warned, err.Key)
+		}))
+	fatal(t, err)
+	if len(warned) != 2 {
+		t.Fatalf("got %#v; want warnings for both unknown keys", warned)
+	}
+
+	err = config.Load(reg, newTestProvider(t, "/etc/test.toml", content), "test", []string{"/etc"}, config.Strict())
+	errs, ok := err.(objects.Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("got %#v; want 2 unknown key errors", err)
+	}
+	unknown := errs[0].(*config.UnknownKeyError)
+	if unknown.Key != "testcomponnet" || unknown.Suggestion != "TestComponent" {
+		t.Fatalf("got %#v; want suggestion for typo", unknown)
+	}
+	if errs[1].(*config.UnknownKeyError).Suggestion != "" {
+		t.Fatalf("got %#v; want no suggestion for unrelated key", errs[1])
+	}
+}
+
+func TestAliases(t *testing.T) {
+	reg := &objects.Registry{}
+	obj := &TestComponent{}
+	other := &objects.Object{Value: &TestComponent{}, Name: "Other", Aliases: []string{"OldOther"}}
+	reg.Register(&objects.Object{Value: obj, Aliases: []string{"OldComponent"}}, other)
+	var deprecated []string
+	reg.Subscribe(func(e objects.Event) {
+		if e.Type == objects.EventDeprecatedName {
+			deprecated = append(deprecated, e.Name)
+		}
+	})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[OldComponent]
+foo = "foobar"
+
+[disabled]
+OldOther = true
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	fatal(t, err)
+	if obj.Foo != "foobar" {
+		t.Fatalf("got %#v; want %#v", obj.Foo, "foobar")
+	}
+	if other.Enabled {
+		t.Fatal("object not disabled by alias")
+	}
+	if len(deprecated) != 2 {
+		t.Fatalf("got %#v; want deprecation for both aliases", deprecated)
+	}
+}
+
+type LayeredComponent struct {
+	System string
+	Env    string
+	Local  string
+}
+
+func (c *LayeredComponent) InitializeConfig(cfg config.Settings) error {
+	return cfg.Unmarshal(c)
+}
+
+func TestEnvPathPrecedence(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/system/layered.toml": "[LayeredComponent]\nsystem = \"system\"\nenv = \"system\"\nlocal = \"system\"\n",
+		"/env/layered.toml":    "[LayeredComponent]\nenv = \"env\"\nlocal = \"env\"\n",
+		"/local/layered.toml":  "[LayeredComponent]\nlocal = \"local\"\n",
+	}
+	for path, content := range files {
+		fatal(t, afero.WriteFile(fs, path, []byte(content), 0644))
+	}
+	v := viperlib.New()
+	v.SetFs(fs)
+	os.Setenv("LAYERED_CONFIG", "/env")
+	defer os.Unsetenv("LAYERED_CONFIG")
+
+	reg := &objects.Registry{}
+	obj := &LayeredComponent{}
+	reg.Register(&objects.Object{Value: obj})
+	err := config.Load(reg, &viper.Provider{Viper: v}, "layered", []string{"/system"},
+		config.LocalOverride("/local"))
+	fatal(t, err)
+	want := LayeredComponent{System: "system", Env: "env", Local: "local"}
+	if *obj != want {
+		t.Fatalf("got %#v; want %#v", *obj, want)
+	}
+
+	// without the option, every path comes before the environment's
+	reg = &objects.Registry{}
+	obj = &LayeredComponent{}
+	reg.Register(&objects.Object{Value: obj})
+	err = config.Load(reg, &viper.Provider{Viper: v}, "layered", []string{"/system", "/local"})
+	fatal(t, err)
+	if obj.Local != "env" {
+		t.Fatalf("got %#v; want environment path to take precedence", obj.Local)
+	}
+}
+
 func TestEnvOverride(t *testing.T) {
 	reg := &objects.Registry{}
 	obj := &TestComponent{}
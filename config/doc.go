@@ -7,15 +7,27 @@
 //
 //   1. configuration is loaded from one or more files via Load
 //   2. the config format(s) are up to the config provider
-//   3. top level keys map to registered object names matched via Lookup
+//   3. top level keys map to registered object names or aliases matched via Lookup
 //   4. a special "disabled" top level key is used to disable registered objects
+//      by name or glob pattern
 //   5. files are loaded from paths that the app specifies in call to Load
 //   6. more filepaths can be specified via user environment variable
+//      (NAME_CONFIG, colon separated), which come before any local override
+//      paths the app gives with the LocalOverride option
 //   7. config can be set or overridden by user environment variables
-//   8. resulting config for each object is passed via extension point
+//   8. resulting config for each object is validated against its schema, if any,
+//      unless the object is disabled, and passed via extension point
 //   9. objects use this to specify defaults, process, and store values
-//   10. "config" fields of an object are assigned by lookup using the key by that field name
-//   11. registry is reloaded
+//   10. "config" fields of an object are assigned by lookup using the key by that field name,
+//       and "extpoint" fields are ordered by a list of object names under that key
+//   11. registry is reloaded, failing if required fields are left unassigned
+//
+// Watch can be used in place of Load to also reload configuration when config
+// files change. Objects whose settings changed are passed their old and new
+// Settings if they implement the Reconfigurer interface.
+//
+// Explain reports where a value in Settings came from, such as a config file
+// and line or an environment variable, for providers implementing Explainer.
 //
 // The default, preferred, and builtin configuration provider is Viper. Viper
 // can be used directly for more control, or replaced with a custom provider.
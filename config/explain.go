@@ -0,0 +1,65 @@
+package config
+
+import "fmt"
+
+// SourceKind is the kind of place a configuration value came from.
+type SourceKind string
+
+const (
+	// SourceFile is a value from a config file.
+	SourceFile SourceKind = "file"
+
+	// SourceEnv is a value from an environment variable.
+	SourceEnv SourceKind = "env"
+
+	// SourceDefault is a value set with SetDefault.
+	SourceDefault SourceKind = "default"
+
+	// SourceOverride is a value set directly by the app, overriding all others.
+	SourceOverride SourceKind = "override"
+
+	// SourceUnknown is a value set somewhere the provider can't tell, for
+	// example in one of several config files it can't read again on its own.
+	SourceUnknown SourceKind = "unknown"
+)
+
+// Source describes where a configuration value came from. File is set for
+// SourceFile, along with Line if the provider can tell, and Env is set for
+// SourceEnv.
+type Source struct {
+	Kind SourceKind
+	File string
+	Line int
+	Env  string
+}
+
+func (s Source) String() string {
+	switch {
+	case s.Kind == SourceFile && s.Line > 0:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	case s.Kind == SourceFile:
+		return s.File
+	case s.Kind == SourceEnv:
+		return fmt.Sprintf("env %s", s.Env)
+	default:
+		return string(s.Kind)
+	}
+}
+
+// Explainer is an optional interface for Settings that can tell where their
+// values came from.
+type Explainer interface {
+	// Source returns where the value of a key came from, or false if the key
+	// is not set.
+	Source(key string) (Source, bool)
+}
+
+// Explain returns where the value of a key in Settings came from. It returns
+// false if the key is not set or the Settings don't implement Explainer.
+func Explain(settings Settings, key string) (Source, bool) {
+	e, ok := settings.(Explainer)
+	if !ok {
+		return Source{}, false
+	}
+	return e.Source(key)
+}
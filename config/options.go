@@ -0,0 +1,43 @@
+package config
+
+// Option changes how Load and Watch load configuration.
+type Option func(*options)
+
+type options struct {
+	strict    bool
+	onUnknown func(*UnknownKeyError)
+	local     []string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Strict makes loading fail if any top-level config keys don't match a
+// registered object, returning an UnknownKeyError for each in objects.Errors.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// OnUnknownKey calls fn for each top-level config key that doesn't match a
+// registered object, for example to log warnings, without failing.
+func OnUnknownKey(fn func(*UnknownKeyError)) Option {
+	return func(o *options) {
+		o.onUnknown = fn
+	}
+}
+
+// LocalOverride adds paths for local override config, which are loaded after
+// paths from the NAME_CONFIG environment variable. So with providers that
+// merge config files, files in them take precedence over all others.
+func LocalOverride(paths ...string) Option {
+	return func(o *options) {
+		o.local = append(o.local, paths...)
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gliderlabs/com/objects"
+)
+
+const schemaTag = "config"
+
+// Schemer is an optional extension point interface for objects to declare the
+// configuration they accept, which Load validates before calling
+// InitializeConfig.
+type Schemer interface {
+	// ConfigSchema returns a struct or struct pointer with fields tagged to
+	// declare rules for their keys, as described by Validate.
+	ConfigSchema() interface{}
+}
+
+// SchemaError is a problem with the value of a config key found by Validate.
+// Path is the full key, including the object section for errors from Load.
+type SchemaError struct {
+	Path    string
+	Problem string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("config %s: %s", e.Path, e.Problem)
+}
+
+// Validate checks Settings against a schema struct and returns SchemaErrors
+// for every problem found together in objects.Errors. Fields are matched to
+// keys like Unmarshal does, by mapstructure tag or field name, and nested
+// structs are checked as sub keys. Rules are set with comma separated options
+// in a config tag:
+//
+//	Port int      `config:"required,min=1,max=65535"`
+//	Mode string   `config:"enum=dev|prod"`
+//	Name string   `config:"pattern=^[a-z]+$"`
+//	Tags []string `config:"min=1"`
+//
+// Min and max are compared to numbers, or to the length of strings, slices,
+// and maps. Rules other than required only apply to keys that are set.
+// Options are split on commas, so patterns can't contain them.
+func Validate(settings Settings, schema interface{}) error {
+	if errs := validateSchema(settings, schema, ""); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateSchema(settings Settings, schema interface{}, prefix string) objects.Errors {
+	t := reflect.TypeOf(schema)
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateStruct(settings, t, prefix, "", make(map[reflect.Type]bool))
+}
+
+func validateStruct(settings Settings, t reflect.Type, prefix, path string, seen map[reflect.Type]bool) objects.Errors {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	var errs objects.Errors
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("com") != "" {
+			continue
+		}
+		name, squash := mapstructureName(field)
+		if name == "-" {
+			continue
+		}
+		key := path
+		if !squash {
+			key = joinKey(path, name)
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && field.Tag.Get(schemaTag) == "" {
+			errs = append(errs, validateStruct(settings, ft, prefix, key, seen)...)
+			continue
+		}
+		errs = append(errs, validateField(settings, field, prefix, key)...)
+	}
+	return errs
+}
+
+// mapstructureName returns the key a field is unmarshaled from, and whether
+// an embedded struct is squashed into its parent.
+func mapstructureName(field reflect.StructField) (string, bool) {
+	parts := strings.Split(field.Tag.Get("mapstructure"), ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "squash" {
+			return strings.ToLower(name), true
+		}
+	}
+	return strings.ToLower(name), false
+}
+
+func joinKey(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func validateField(settings Settings, field reflect.StructField, prefix, key string) objects.Errors {
+	tag := field.Tag.Get(schemaTag)
+	if tag == "" {
+		return nil
+	}
+	var errs objects.Errors
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &SchemaError{Path: joinKey(prefix, key), Problem: fmt.Sprintf(format, args...)})
+	}
+	set := settings.IsSet(key)
+	value := settings.Get(key)
+	for _, opt := range strings.Split(tag, ",") {
+		kv := strings.SplitN(strings.TrimSpace(opt), "=", 2)
+		rule, arg := kv[0], ""
+		if len(kv) == 2 {
+			arg = kv[1]
+		}
+		if rule == "required" {
+			if !set {
+				fail("required but not set")
+			}
+			continue
+		}
+		if !set {
+			continue
+		}
+		switch rule {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				fail("invalid %s rule %q", rule, arg)
+				continue
+			}
+			n, what, ok := measure(value, field.Type)
+			if !ok {
+				fail("%v is not a number", value)
+				continue
+			}
+			if rule == "min" && n < limit {
+				fail("%s must be at least %s", what, arg)
+			}
+			if rule == "max" && n > limit {
+				fail("%s must be at most %s", what, arg)
+			}
+		case "enum":
+			allowed := strings.Split(arg, "|")
+			found := false
+			for _, a := range allowed {
+				if fmt.Sprint(value) == a {
+					found = true
+				}
+			}
+			if !found {
+				fail("%q must be one of %s", fmt.Sprint(value), strings.Join(allowed, ", "))
+			}
+		case "pattern":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				fail("invalid pattern rule %q", arg)
+				continue
+			}
+			if !re.MatchString(fmt.Sprint(value)) {
+				fail("%q must match %s", fmt.Sprint(value), arg)
+			}
+		default:
+			fail("unknown rule %q", rule)
+		}
+	}
+	return errs
+}
+
+// measure returns what min and max compare for a value, which is its length
+// for string, slice, and map fields, and otherwise its number.
+func measure(value interface{}, t reflect.Type) (float64, string, bool) {
+	switch t.Kind() {
+	case reflect.String:
+		return float64(len(fmt.Sprint(value))), "length", true
+	case reflect.Slice, reflect.Map:
+		v := reflect.ValueOf(value)
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+			return float64(v.Len()), "length", true
+		}
+		// lists from environment are comma separated strings
+		return float64(len(toStrings(value))), "length", true
+	}
+	n, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+	return n, "value", err == nil
+}
+
+// schemaOf returns the schema of an object's config, from Schemer or the
+// object value itself if it has fields with config tags.
+func schemaOf(value interface{}) interface{} {
+	if s, ok := value.(Schemer); ok {
+		return s.ConfigSchema()
+	}
+	t := reflect.TypeOf(value)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if !hasSchemaTags(t.Elem(), make(map[reflect.Type]bool)) {
+		return nil
+	}
+	return value
+}
+
+func hasSchemaTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("com") != "" {
+			continue
+		}
+		if field.Tag.Get(schemaTag) != "" {
+			return true
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasSchemaTags(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/gliderlabs/com/config"
+	"github.com/gliderlabs/com/objects"
+)
+
+type Server struct {
+	Port        int    `config:"required,min=1,max=65535"`
+	Mode        string `config:"enum=dev|prod"`
+	initialized bool
+}
+
+func (s *Server) InitializeConfig(cfg config.Settings) error {
+	s.initialized = true
+	return cfg.Unmarshal(s)
+}
+
+type Client struct {
+	initialized bool
+}
+
+func (c *Client) ConfigSchema() interface{} {
+	return &struct {
+		Name string
+		Pool struct {
+			Hosts []string `config:"min=1"`
+		}
+		Host string `config:"pattern=^[a-z.]+$"`
+	}{}
+}
+
+func (c *Client) InitializeConfig(cfg config.Settings) error {
+	c.initialized = true
+	return nil
+}
+
+func TestSchemaValidation(t *testing.T) {
+	reg := &objects.Registry{}
+	server := &Server{}
+	client := &Client{}
+	reg.Register(&objects.Object{Value: server}, &objects.Object{Value: client})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[Server]
+port = 70000
+mode = "staging"
+
+[Client]
+host = "Example.com"
+
+[Client.pool]
+hosts = []
+`)
+	err := config.Load(reg, provider, "test", []string{"/etc"})
+	errs, ok := err.(objects.Errors)
+	if !ok {
+		t.Fatalf("got %#v; want schema errors", err)
+	}
+	var paths []string
+	for _, err := range errs {
+		paths = append(paths, err.(*config.SchemaError).Path)
+	}
+	want := []string{"server.port", "server.mode", "client.pool.hosts", "client.host"}
+	if !equalStrings(paths, want) {
+		t.Fatalf("got %#v; want errors for %#v", paths, want)
+	}
+	if server.initialized || client.initialized {
+		t.Fatal("objects initialized despite invalid config")
+	}
+
+	provider = newTestProvider(t, "/etc/test.toml", `
+[Server]
+port = 8080
+mode = "prod"
+`)
+	fatal(t, config.Load(reg, provider, "test", []string{"/etc"}))
+	if server.Port != 8080 || !client.initialized {
+		t.Fatal("objects not initialized with valid config")
+	}
+}
+
+func TestSchemaSkipsDisabled(t *testing.T) {
+	reg := &objects.Registry{}
+	reg.Register(&objects.Object{Value: &Server{}})
+	provider := newTestProvider(t, "/etc/test.toml", `
+[Server]
+port = 70000
+
+[disabled]
+Server = true
+`)
+	fatal(t, config.Load(reg, provider, "test", []string{"/etc"}))
+}
+
+func TestValidateRequired(t *testing.T) {
+	provider := newTestProvider(t, "/etc/test.toml", ``)
+	settings, err := provider.Load("test", []string{"/etc"})
+	fatal(t, err)
+	err = config.Validate(settings, &Server{})
+	errs, ok := err.(objects.Errors)
+	if !ok || len(errs) != 1 || errs[0].Error() != "config port: required but not set" {
+		t.Fatalf("got %#v; want required error", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
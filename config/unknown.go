@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gliderlabs/com/objects"
+)
+
+// UnknownKeyError is a top-level config key that doesn't match a registered
+// object, usually because of a typo. Suggestion is the closest name or FQN of
+// a registered object, if any is close enough.
+type UnknownKeyError struct {
+	Key        string
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown config key %q; did you mean %q?", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown config key %q", e.Key)
+}
+
+// reportUnknown reports top-level keys that didn't match any object, in
+// sorted order, as the options ask for.
+func (o *options) reportUnknown(registry *objects.Registry, keys []string) error {
+	if !o.strict && o.onUnknown == nil {
+		return nil
+	}
+	sort.Strings(keys)
+	var errs objects.Errors
+	for _, key := range keys {
+		if strings.EqualFold(key, disabledKey) {
+			continue
+		}
+		err := &UnknownKeyError{Key: key, Suggestion: suggest(registry, key)}
+		if o.onUnknown != nil {
+			o.onUnknown(err)
+		}
+		errs = append(errs, err)
+	}
+	if !o.strict || len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// suggest returns the name, alias, or FQN of a registered object closest to a
+// key by edit distance, if within a third of the key's length.
+func suggest(registry *objects.Registry, key string) string {
+	best, bestDistance := "", len(key)/3+1
+	for _, obj := range registry.Objects() {
+		for _, candidate := range append([]string{obj.Name, obj.FQN()}, obj.Aliases...) {
+			d := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+			if d < bestDistance {
+				best, bestDistance = candidate, d
+			}
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -2,8 +2,13 @@
 // library. Since the config.Provider interface was borrowed from Viper, this is
 // a very light package implementation.
 //
-// The Load implementation not only loads config files from multiple paths, it
-// uses Viper's AutomaticEnv to load config from environment. It also uses
+// The Load implementation not only merges config files from multiple paths,
+// with files in later paths taking precedence, it uses Viper's AutomaticEnv to
+// load config from environment. It also uses
 // SetEnvKeyReplacer to use underscores in place of periods when identifying
 // sub keys via environment.
+//
+// Provider also implements config.Explainer, so config.Explain can tell
+// whether a value came from the config file, with its line for TOML files,
+// from the environment, or from a default.
 package viper
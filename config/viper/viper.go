@@ -1,21 +1,44 @@
 package viper
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gliderlabs/com/config"
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 )
 
+var envReplacer = strings.NewReplacer(".", "_")
+
 // New returns an initialized Viper provider instance.
 func New() config.Provider {
 	v := viper.New()
-	return &Provider{v}
+	return &Provider{Viper: v}
 }
 
-// Provider is a config.Provider for Viper.
+// Provider is a config.Provider for Viper. It implements config.Explainer to
+// tell where values came from, as long as defaults and overrides are set with
+// its SetDefault and Set methods, and a filesystem other than the OS one is set
+// with its SetFs method.
+//
+// Provider keeps unexported state besides the embedded Viper, so it must be
+// created with New or a keyed literal like &Provider{Viper: v}. Unkeyed
+// literals like &Provider{v}, which worked when Viper was its only field, no
+// longer compile.
 type Provider struct {
 	*viper.Viper
+
+	fs        afero.Fs
+	files     []string
+	layers    map[string]*viper.Viper
+	parent    *Provider
+	prefix    string
+	defaults  map[string]bool
+	overrides map[string]bool
 }
 
 // Sub returns new Settings instance representing a sub tree of this instance.
@@ -29,7 +52,7 @@ func (p *Provider) Sub(key string) config.Settings {
 	for k, v := range keys[key] {
 		sub.Set(k, v)
 	}
-	return &Provider{sub}
+	return &Provider{Viper: sub, parent: p, prefix: strings.ToLower(key)}
 }
 
 // New returns an empty Settings instance.
@@ -37,30 +60,248 @@ func (p *Provider) New() config.Settings {
 	return New()
 }
 
+// SetDefault sets the default value for this key.
+func (p *Provider) SetDefault(key string, value interface{}) {
+	if p.defaults == nil {
+		p.defaults = make(map[string]bool)
+	}
+	p.defaults[strings.ToLower(key)] = true
+	p.Viper.SetDefault(key, value)
+}
+
+// SetFs sets the filesystem config files are read from. Use it instead of
+// Viper's SetFs so Source can read each file again to tell which set a value.
+func (p *Provider) SetFs(fs afero.Fs) {
+	p.fs = fs
+	p.Viper.SetFs(fs)
+}
+
+// Set sets the value for this key, overriding values from all other sources.
+func (p *Provider) Set(key string, value interface{}) {
+	if p.overrides == nil {
+		p.overrides = make(map[string]bool)
+	}
+	p.overrides[strings.ToLower(key)] = true
+	p.Viper.Set(key, value)
+}
+
+// Source returns where the value of a key came from, following the same
+// precedence as Viper: overrides, then environment, then config file, then
+// defaults. Lines are only found for TOML config files. If a config file that
+// may set the key couldn't be read again on its own, for example because
+// Viper's filesystem was set directly instead of with SetFs, the source is
+// config.SourceUnknown.
+func (p *Provider) Source(key string) (config.Source, bool) {
+	key = strings.ToLower(key)
+	if !p.IsSet(key) {
+		return config.Source{}, false
+	}
+	if p.overrides[key] {
+		return config.Source{Kind: config.SourceOverride}, true
+	}
+	if p.parent != nil {
+		if src, ok := p.parent.Source(p.prefix + "." + key); ok {
+			return src, true
+		}
+	} else {
+		env := strings.ToUpper(envReplacer.Replace(key))
+		if _, ok := os.LookupEnv(env); ok {
+			return config.Source{Kind: config.SourceEnv, Env: env}, true
+		}
+		file, known := p.fileOf(key)
+		if !known {
+			return config.Source{Kind: config.SourceUnknown}, true
+		}
+		if file != "" {
+			return config.Source{Kind: config.SourceFile, File: file, Line: p.tomlLine(file, key)}, true
+		}
+	}
+	if p.defaults[key] {
+		return config.Source{Kind: config.SourceDefault}, true
+	}
+	return config.Source{}, false
+}
+
+// fileOf returns the last merged config file setting a key, or "" if none
+// does. It returns false if that can't be told because a file merged after
+// any found to set the key couldn't be read again on its own.
+func (p *Provider) fileOf(key string) (string, bool) {
+	for i := len(p.files) - 1; i >= 0; i-- {
+		layer, ok := p.layers[p.files[i]]
+		if !ok {
+			return "", false
+		}
+		if hasKey(layer.AllSettings(), key) {
+			return p.files[i], true
+		}
+	}
+	return "", true
+}
+
+// hasKey returns true if a nested settings map has a dotted key. Viper's
+// InConfig can't be used since it only checks top-level keys.
+func hasKey(settings map[string]interface{}, key string) bool {
+	parts := strings.SplitN(key, ".", 2)
+	for k, v := range settings {
+		if !strings.EqualFold(k, parts[0]) {
+			continue
+		}
+		if len(parts) == 1 {
+			return true
+		}
+		switch sub := v.(type) {
+		case map[string]interface{}:
+			return hasKey(sub, parts[1])
+		case map[interface{}]interface{}:
+			m := make(map[string]interface{}, len(sub))
+			for sk, sv := range sub {
+				m[fmt.Sprint(sk)] = sv
+			}
+			return hasKey(m, parts[1])
+		}
+		return false
+	}
+	return false
+}
+
 // Load returns Settings for named configuration loaded from provided paths.
 // Leave the file extension off of name as supported format extensions will
 // automatically be added by Viper.
+//
+// Every config file found is merged in the order of paths, so files in later
+// paths take precedence, for example system, then user, then local override
+// paths. Object sections are deep merged key by key, so a later file only
+// needs the keys it changes. Within a path, files are merged in the order of
+// Viper's supported extensions.
 func (p *Provider) Load(name string, paths []string) (config.Settings, error) {
+	// start fresh so config from files that are gone isn't kept
+	if err := p.reset(); err != nil {
+		return nil, err
+	}
+
 	// read in config files
 	if len(paths) > 0 && name != "" {
-		p.SetConfigName(name)
 		for _, path := range paths {
-			p.AddConfigPath(path)
-		}
-		if err := p.ReadInConfig(); err != nil {
-			switch err.(type) {
-			case viper.ConfigFileNotFoundError:
-				// it's fine!
-				break
-			default:
-				return nil, err
+			if path == "" {
+				continue
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			for _, ext := range viper.SupportedExts {
+				if err := p.merge(filepath.Join(path, name+"."+ext)); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
 	// read config from environment
 	p.AutomaticEnv()
-	p.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	p.SetEnvKeyReplacer(envReplacer)
 
 	return p, nil
 }
+
+// reset clears config read from files by a previous Load, keeping defaults,
+// overrides, and config from the environment. The empty config is parsed as
+// the type of the last file read, and JSON is the only supported type where an
+// empty document isn't valid.
+func (p *Provider) reset() error {
+	if len(p.files) == 0 {
+		return nil
+	}
+	last := p.files[len(p.files)-1]
+	p.files = nil
+	p.layers = make(map[string]*viper.Viper)
+	empty := ""
+	if strings.ToLower(filepath.Ext(last)) == ".json" {
+		empty = "{}"
+	}
+	p.SetConfigFile(last)
+	return p.ReadConfig(strings.NewReader(empty))
+}
+
+// merge merges a config file if it exists. The first file found replaces
+// any config read into the Viper instance before.
+func (p *Provider) merge(file string) error {
+	p.SetConfigFile(file)
+	read := p.MergeInConfig
+	if len(p.files) == 0 {
+		read = p.ReadInConfig
+	}
+	if err := read(); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if p.layers == nil {
+		p.layers = make(map[string]*viper.Viper)
+	}
+	p.files = append(p.files, file)
+	// keep each file's own config to tell where values came from, if it can
+	// be read from the provider's filesystem
+	layer := viper.New()
+	layer.SetFs(p.filesystem())
+	layer.SetConfigFile(file)
+	if layer.ReadInConfig() == nil {
+		p.layers[file] = layer
+	}
+	return nil
+}
+
+// filesystem returns the filesystem set with SetFs, or the OS filesystem.
+func (p *Provider) filesystem() afero.Fs {
+	if p.fs == nil {
+		return afero.NewOsFs()
+	}
+	return p.fs
+}
+
+// tomlLine returns the line a key is set on in a TOML file, or 0 if it can't
+// be found. It understands table headers and keys, which covers how config
+// files for objects are usually written.
+func (p *Provider) tomlLine(file, key string) int {
+	if strings.ToLower(filepath.Ext(file)) != ".toml" {
+		return 0
+	}
+	f, err := p.filesystem().Open(file)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	table := ""
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, "[") && !strings.HasPrefix(text, "[["):
+			table = tomlKey(strings.TrimSuffix(strings.TrimPrefix(text, "["), "]"))
+		default:
+			i := strings.Index(text, "=")
+			if i < 0 {
+				continue
+			}
+			k := tomlKey(text[:i])
+			if table != "" {
+				k = table + "." + k
+			}
+			if k == key {
+				return line
+			}
+		}
+	}
+	return 0
+}
+
+// tomlKey normalizes a TOML key, removing quotes around its parts.
+func tomlKey(s string) string {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.Trim(strings.TrimSpace(part), `"'`))
+	}
+	return strings.Join(parts, ".")
+}
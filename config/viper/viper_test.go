@@ -1,8 +1,12 @@
 package viper
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gliderlabs/com/config"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 )
@@ -15,7 +19,7 @@ func newTestProvider(t *testing.T, path, config string) *Provider {
 	}
 	v := viper.New()
 	v.SetFs(fs)
-	return &Provider{v}
+	return &Provider{Viper: v}
 }
 
 func getString(v interface{}) string {
@@ -106,10 +110,121 @@ func TestSub(t *testing.T) {
 	v.Set("sub", map[string]interface{}{
 		"key": "value",
 	})
-	provider := &Provider{v}
+	provider := &Provider{Viper: v}
 	sub := provider.Sub("sub")
 	val := getString(sub.Get("key"))
 	if val != "value" {
 		t.Fatalf("expected 'value', got '%#v'", val)
 	}
 }
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	fatal(t, err)
+	defer os.RemoveAll(dir)
+	fatal(t, ioutil.WriteFile(filepath.Join(dir, "test.toml"), []byte(`
+[Source]
+file = "file"
+env = "file"
+`), 0644))
+	os.Setenv("SOURCE_ENV", "env")
+	defer os.Unsetenv("SOURCE_ENV")
+
+	provider := New()
+	settings, err := provider.Load("test", []string{dir})
+	fatal(t, err)
+	sub := settings.Sub("Source")
+	sub.SetDefault("default", "default")
+	sub.SetDefault("file", "default")
+
+	want := map[string]string{
+		"file":    filepath.Join(dir, "test.toml") + ":3",
+		"env":     "env SOURCE_ENV",
+		"default": "default",
+	}
+	for key, want := range want {
+		src, ok := config.Explain(sub, key)
+		if !ok || src.String() != want {
+			t.Fatalf("got %v for %s; want %s", src, key, want)
+		}
+	}
+	if _, ok := config.Explain(sub, "missing"); ok {
+		t.Fatal("source found for key that is not set")
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	var dirs []string
+	for _, file := range []string{"test.toml", "test.yaml"} {
+		dir, err := ioutil.TempDir("", "merge")
+		fatal(t, err)
+		defer os.RemoveAll(dir)
+		dirs = append(dirs, dir)
+		content := "[Merged]\nsystem = \"system\"\nlocal = \"system\"\n"
+		if file == "test.yaml" {
+			content = "Merged:\n  local: local\n"
+		}
+		fatal(t, ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	}
+	provider := New()
+	settings, err := provider.Load("test", append(dirs, "/nonexistent"))
+	fatal(t, err)
+	sub := settings.Sub("Merged")
+	if got := getString(sub.Get("system")); got != "system" {
+		t.Fatalf("got %#v; want value kept from first file", got)
+	}
+	if got := getString(sub.Get("local")); got != "local" {
+		t.Fatalf("got %#v; want value overridden by later file", got)
+	}
+	if src, _ := config.Explain(sub, "system"); src.File != filepath.Join(dirs[0], "test.toml") {
+		t.Fatalf("got %v; want first file as source", src)
+	}
+	if src, _ := config.Explain(sub, "local"); src.File != filepath.Join(dirs[1], "test.yaml") {
+		t.Fatalf("got %v; want later file as source", src)
+	}
+}
+
+func TestLoadAgainWithoutFiles(t *testing.T) {
+	for _, file := range []string{"test.toml", "test.json"} {
+		provider := newTestProvider(t, "/etc/"+file, `{"Test": {"foo": "foobar"}}`)
+		if file == "test.toml" {
+			provider = newTestProvider(t, "/etc/"+file, "[Test]\nfoo = \"foobar\"\n")
+		}
+		_, err := provider.Load("test", []string{"/etc"})
+		fatal(t, err)
+		settings, err := provider.Load("test", []string{"/nonexistent"})
+		fatal(t, err)
+		if settings.IsSet("Test.foo") {
+			t.Fatalf("config from %s kept after it was no longer found", file)
+		}
+	}
+}
+
+func TestSourceFs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	fatal(t, afero.WriteFile(fs, "/etc/test.toml", []byte("[Source]\nfile = \"file\"\n"), 0644))
+	provider := &Provider{Viper: viper.New()}
+	provider.SetFs(fs)
+	provider.SetDefault("top", 1)
+	settings, err := provider.Load("test", []string{"/etc"})
+	fatal(t, err)
+	want := map[string]string{
+		"source.file": "/etc/test.toml:2",
+		"top":         "default",
+	}
+	for key, want := range want {
+		src, ok := config.Explain(settings, key)
+		if !ok || src.String() != want {
+			t.Fatalf("got %v for %s; want %s", src, key, want)
+		}
+	}
+
+	// files can't be read again through Viper's own filesystem
+	provider = newTestProvider(t, "/etc/test.toml", "[Source]\nfile = \"file\"\n")
+	provider.SetDefault("top", 1)
+	settings, err = provider.Load("test", []string{"/etc"})
+	fatal(t, err)
+	if src, _ := config.Explain(settings, "top"); src.Kind != config.SourceUnknown {
+		t.Fatalf("got %v; want unknown source", src)
+	}
+}
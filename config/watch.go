@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/com/objects"
+)
+
+// DefaultWatchInterval is how often a Watcher checks config files by default.
+const DefaultWatchInterval = 5 * time.Second
+
+// Watcher reloads configuration when the config files it was loaded from
+// change. Files are polled, so a Watcher works with any editor or deployment
+// tool that replaces files instead of writing them in place.
+//
+// On reload, the load pipeline is run again with the same Provider, which must
+// support being loaded more than once. Objects whose Settings changed have
+// ReconfigureConfig called if they implement Reconfigurer and their config
+// fields assigned again, and the disabled section is applied again, enabling
+// objects it no longer disables. Objects whose Settings are unchanged are left
+// untouched.
+type Watcher struct {
+	// Interval is how often Run checks for changes. Zero means
+	// DefaultWatchInterval.
+	Interval time.Duration
+
+	// OnError is called by Run with errors from reloading, which otherwise
+	// leave the previous configuration in place.
+	OnError func(error)
+
+	mu       sync.Mutex
+	options  *options
+	registry *objects.Registry
+	provider Provider
+	name     string
+	paths    []string
+	loaded   *loaded
+	files    map[string]string
+}
+
+// Watch loads configuration like Load and returns a Watcher for reloading it
+// when config files change. Call Run to start watching, or Check to check for
+// changes manually. Options apply to every reload.
+func Watch(registry *objects.Registry, provider Provider, name string, paths []string, opts ...Option) (*Watcher, error) {
+	o := newOptions(opts)
+	w := &Watcher{
+		options:  o,
+		registry: registry,
+		provider: provider,
+		name:     name,
+		paths:    withEnvPaths(name, paths, o.local),
+	}
+	w.files = w.stat()
+	loaded, err := load(registry, provider, name, w.paths, nil, w.options)
+	if err != nil {
+		return nil, err
+	}
+	w.loaded = loaded
+	return w, nil
+}
+
+// Run checks for changes every Interval until the context is done, which is
+// the error returned.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := w.Check(); err != nil && w.OnError != nil {
+				w.OnError(err)
+			}
+		}
+	}
+}
+
+// Check reloads configuration if any config file was added, removed, or
+// modified since it was last loaded. It returns true if it reloaded.
+func (w *Watcher) Check() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	files := w.stat()
+	if equalFiles(files, w.files) {
+		return false, nil
+	}
+	w.files = files
+	return true, w.reload()
+}
+
+// Reload reloads configuration whether or not config files changed.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = w.stat()
+	return w.reload()
+}
+
+func (w *Watcher) reload() error {
+	loaded, err := load(w.registry, w.provider, w.name, w.paths, w.loaded, w.options)
+	if loaded != nil {
+		w.loaded = loaded
+	}
+	return err
+}
+
+// stat returns the modification time and size of every file in the config
+// paths named like the config, with any extension.
+func (w *Watcher) stat() map[string]string {
+	files := make(map[string]string)
+	for _, path := range w.paths {
+		if path == "" {
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(path, w.name+".*"))
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			files[match] = fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return files
+}
+
+func equalFiles(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
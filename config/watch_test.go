@@ -0,0 +1,144 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gliderlabs/com/config"
+	"github.com/gliderlabs/com/config/viper"
+	"github.com/gliderlabs/com/objects"
+)
+
+type ReloadComponent struct {
+	Foo     string
+	changes []string
+}
+
+func (c *ReloadComponent) InitializeConfig(cfg config.Settings) error {
+	return cfg.Unmarshal(c)
+}
+
+func (c *ReloadComponent) ReconfigureConfig(old, new config.Settings) error {
+	c.changes = append(c.changes, getString(old.Get("foo"))+" -> "+getString(new.Get("foo")))
+	return new.Unmarshal(c)
+}
+
+func getString(v interface{}) string {
+	vv, _ := v.(string)
+	return vv
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	fatal(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.toml")
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[Changed]
+foo = "old"
+
+[Unchanged]
+foo = "same"
+`), 0644))
+
+	reg := &objects.Registry{}
+	changed := &ReloadComponent{}
+	unchanged := &ReloadComponent{}
+	reg.Register(&objects.Object{Value: changed, Name: "Changed"},
+		&objects.Object{Value: unchanged, Name: "Unchanged"})
+	w, err := config.Watch(reg, viper.New(), "test", []string{dir})
+	fatal(t, err)
+	if changed.Foo != "old" {
+		t.Fatalf("got %#v; want %#v", changed.Foo, "old")
+	}
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		t.Fatalf("got %v, %v; want no reload without changes", reloaded, err)
+	}
+
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[Changed]
+foo = "newer"
+
+[Unchanged]
+foo = "same"
+`), 0644))
+	reloaded, err := w.Check()
+	fatal(t, err)
+	if !reloaded {
+		t.Fatal("config not reloaded after change")
+	}
+	if changed.Foo != "newer" || len(changed.changes) != 1 || changed.changes[0] != "old -> newer" {
+		t.Fatalf("got %#v; want changed object reconfigured once", changed.changes)
+	}
+	if len(unchanged.changes) != 0 {
+		t.Fatalf("got %#v; want unchanged object left alone", unchanged.changes)
+	}
+}
+
+func TestWatchInitializesLazy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	fatal(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.toml")
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[Other]
+foo = "bar"
+`), 0644))
+
+	reg := &objects.Registry{}
+	lazy := &ReloadComponent{}
+	err = reg.RegisterFactory(func() *ReloadComponent { return lazy }, "Lazy")
+	fatal(t, err)
+	w, err := config.Watch(reg, viper.New(), "test", []string{dir})
+	fatal(t, err)
+	if lazy.Foo != "" {
+		t.Fatal("lazy object without a section initialized")
+	}
+
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[Lazy]
+foo = "bar"
+`), 0644))
+	fatal(t, w.Reload())
+	if lazy.Foo != "bar" || len(lazy.changes) != 0 {
+		t.Fatalf("got %#v, %#v; want lazy object initialized, not reconfigured", lazy.Foo, lazy.changes)
+	}
+}
+
+func TestWatchReenables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	fatal(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.toml")
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[disabled]
+Named = true
+"*Matched" = true
+`), 0644))
+
+	reg := &objects.Registry{}
+	named := &objects.Object{Value: &ReloadComponent{}, Name: "Named"}
+	matched := &objects.Object{Value: &ReloadComponent{}, Name: "Matched"}
+	other := &objects.Object{Value: &ReloadComponent{}, Name: "Other"}
+	reg.Register(named, matched, other)
+	reg.SetEnabled(other.FQN(), false)
+	w, err := config.Watch(reg, viper.New(), "test", []string{dir})
+	fatal(t, err)
+	if named.Enabled || matched.Enabled {
+		t.Fatal("objects not disabled by config")
+	}
+
+	fatal(t, ioutil.WriteFile(path, []byte(`
+[disabled]
+"*Matched" = false
+`), 0644))
+	fatal(t, w.Reload())
+	if !named.Enabled || !matched.Enabled {
+		t.Fatal("objects no longer disabled by config not enabled again")
+	}
+	if other.Enabled {
+		t.Fatal("object disabled outside config enabled by reload")
+	}
+}
@@ -0,0 +1,158 @@
+package objects
+
+import "reflect"
+
+// NewChild returns a new registry scoped under this one. Objects registered
+// with the child are only visible to the child, but the child can resolve
+// objects from its parent:
+//
+// Lookup tries the child first and then the parent. Singleton fields of child
+// objects are resolved from the child's objects if any match, otherwise from
+// the parent's. Extpoint fields of child objects include matching objects from
+// both, where a child object replaces a parent object with the same FQN.
+//
+// Objects start out disabled in the child if disabled in the parent, but the
+// child can use SetEnabled to override this for both its own objects and the
+// ones it inherits without affecting the parent.
+//
+// The parent never resolves objects from its children, and changes to the
+// parent are only seen by child objects when the child is reloaded. The child
+// also gets a copy of the parent's timeout and RequireAll settings.
+func (r *Registry) NewChild() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &Registry{
+		StartTimeout: r.StartTimeout,
+		StopTimeout:  r.StopTimeout,
+		RequireAll:   r.RequireAll,
+		parent:       r,
+	}
+}
+
+// scope is the set of objects used to resolve fields in a registry. It has the
+// registry's own objects followed by those inherited from its parents, along
+// with whether each is enabled from this registry's point of view.
+type scope struct {
+	objects []*Object
+	own     int
+	enabled map[*Object]bool
+}
+
+// scope returns the objects visible to the registry. The registry is expected
+// to be locked for writing, and parents are read locked as they are visited.
+// Inherited objects are the registry's own copies of objects in its parents,
+// so they can be read and decorated without holding their locks.
+func (r *Registry) scope() *scope {
+	return r.collect(false)
+}
+
+// collect returns the registry's objects followed by those inherited from its
+// parents. If snapshot is set, all of them are snapshots, as needed by a child
+// registry once this one is unlocked, and the registry can be read locked.
+func (r *Registry) collect(snapshot bool) *scope {
+	s := &scope{
+		own:     len(r.objects),
+		enabled: make(map[*Object]bool),
+	}
+	fqns := make(map[string]bool)
+	for _, o := range r.objects {
+		if snapshot {
+			o = o.snapshot()
+		}
+		s.objects = append(s.objects, o)
+		s.enabled[o] = o.Enabled
+		fqns[o.FQN()] = true
+	}
+	if r.parent == nil {
+		return s
+	}
+	r.parent.mu.RLock()
+	inherited := r.parent.collect(true)
+	r.parent.mu.RUnlock()
+	var copies map[*Object]*Object
+	if !snapshot {
+		copies = make(map[*Object]*Object)
+	}
+	for _, o := range inherited.objects {
+		if fqns[o.FQN()] {
+			continue
+		}
+		enabled := inherited.enabled[o]
+		if disabled, ok := r.disabled[o.FQN()]; ok {
+			enabled = !disabled
+		}
+		if !snapshot {
+			o = r.inherit(o)
+			copies[o.registered()] = o
+		}
+		s.objects = append(s.objects, o)
+		s.enabled[o] = enabled
+	}
+	if !snapshot {
+		r.inherited = copies
+	}
+	return s
+}
+
+// inherit returns the registry's copy of an object inherited from a parent,
+// updated from a snapshot of it. Copies keep their identity, and decorations
+// as long as the object value is the same, from one reload to the next.
+func (r *Registry) inherit(o *Object) *Object {
+	c, ok := r.inherited[o.registered()]
+	if !ok {
+		c = &Object{}
+	}
+	c.update(o)
+	return c
+}
+
+// update sets an inherited copy to a newer snapshot of the same object.
+func (o *Object) update(snapshot *Object) {
+	decorations := o.decorations
+	if !o.is(snapshot.reflectValue) {
+		decorations = nil
+	}
+	*o = *snapshot
+	o.decorations = decorations
+}
+
+// object returns the object in scope that an object found some other way, for
+// example by lookup, refers to, or nil if there is none.
+func (s *scope) object(o *Object) *Object {
+	for _, existing := range s.objects {
+		if existing.registered() == o.registered() {
+			return existing
+		}
+	}
+	return nil
+}
+
+// assignable returns the enabled objects in scope assignable to a type,
+// except decorators of the type. If childFirst is set, inherited objects are
+// only returned if none of the registry's own objects are assignable.
+func (s *scope) assignable(t reflect.Type, childFirst bool) []*Object {
+	var matches []*Object
+	for i, o := range s.objects {
+		if childFirst && i == s.own && len(matches) > 0 {
+			break
+		}
+		if s.enabled[o] && o.reflectType.AssignableTo(t) && o.decorates() != t {
+			matches = append(matches, o)
+		}
+	}
+	return matches
+}
+
+// isDisabled returns whether objects with an FQN have been disabled in this
+// registry, or if not set here, in its parents.
+func (r *Registry) isDisabled(fqn string) bool {
+	if disabled, ok := r.disabled[fqn]; ok {
+		return disabled
+	}
+	if r.parent == nil {
+		return false
+	}
+	r.parent.mu.RLock()
+	defer r.parent.mu.RUnlock()
+	return r.parent.isDisabled(fqn)
+}
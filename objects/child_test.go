@@ -0,0 +1,79 @@
+package objects
+
+import "testing"
+
+func TestChildSingletonFallback(t *testing.T) {
+	parent := &Registry{}
+	foo := &Foo{"parent"}
+	if err := parent.Register(&Object{Value: foo}); err != nil {
+		t.Fatal(err)
+	}
+	child := parent.NewChild()
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	if err := child.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != foo {
+		t.Fatal("child object not assigned singleton from parent")
+	}
+	if _, err := child.Lookup("Foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parent.Lookup("v"); err != ErrNotFound {
+		t.Fatalf("got %#v; want ErrNotFound from parent", err)
+	}
+}
+
+func TestChildSingletonOverride(t *testing.T) {
+	parent := &Registry{}
+	if err := parent.Register(&Object{Value: &Foo{"parent"}, Name: "parent"}); err != nil {
+		t.Fatal(err)
+	}
+	child := parent.NewChild()
+	override := &Foo{"child"}
+	var v struct {
+		A *Foo       `com:"singleton"`
+		B []Stringer `com:"extpoint"`
+	}
+	err := child.Register(&Object{Value: override, Name: "child"}, &Object{Value: &v, Name: "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != override {
+		t.Fatal("child object not assigned singleton from child")
+	}
+	if len(v.B) != 2 {
+		t.Fatalf("got %d extensions; want extpoint merged from child and parent", len(v.B))
+	}
+}
+
+func TestChildDisabledInheritance(t *testing.T) {
+	parent := &Registry{}
+	foo := &Object{Value: &Foo{"parent"}}
+	if err := parent.Register(foo); err != nil {
+		t.Fatal(err)
+	}
+	parent.SetEnabled(foo.FQN(), false)
+	child := parent.NewChild()
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	if err := child.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != nil {
+		t.Fatal("child object assigned singleton disabled in parent")
+	}
+	child.SetEnabled(foo.FQN(), true)
+	if err := child.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v.A == nil {
+		t.Fatal("child object not assigned singleton enabled in child")
+	}
+	if foo.Enabled {
+		t.Fatal("enabling in child affected parent")
+	}
+}
@@ -0,0 +1,122 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Decorator is an extension point interface for objects that wrap other
+// objects when they are injected, for example to add metrics, retries, or
+// tracing without changing the objects that use them.
+//
+// Enabled decorators of an interface are applied to every object injected
+// into singleton and extpoint fields and constructor parameters of that
+// interface type. They are ordered like extension points, by priority and
+// then FQN, and the first decorator in that order wraps all the others.
+// Decorators are never injected as the interface they decorate themselves.
+type Decorator interface {
+	// Decorates returns a nil pointer to the interface type decorated, for
+	// example (*Store)(nil).
+	Decorates() interface{}
+
+	// Decorate returns a value wrapping v that implements the decorated
+	// interface. It should be a pointer so the registry can tell it apart
+	// from values set some other way. It is called once per object and chain
+	// of decorators, and the result is reused across reloads.
+	Decorate(v interface{}) interface{}
+}
+
+var decoratorType = reflect.TypeOf((*Decorator)(nil)).Elem()
+
+// decoration identifies a decorated value of an object by the interface it
+// was decorated as and the FQNs of the decorators applied.
+type decoration struct {
+	iface reflect.Type
+	chain string
+}
+
+// decorates returns the interface type an object decorates, or nil if it is
+// not a Decorator.
+func (o *Object) decorates() reflect.Type {
+	d, ok := o.Value.(Decorator)
+	if !ok {
+		return nil
+	}
+	t := reflect.TypeOf(d.Decorates())
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return nil
+	}
+	return t.Elem()
+}
+
+// ensureDecorators constructs lazy decorators so what they decorate is known
+// before fields are populated.
+func (r *Registry) ensureDecorators(s *scope) error {
+	for _, o := range s.objects {
+		if !s.enabled[o] || !o.lazy() || !o.reflectType.Implements(decoratorType) {
+			continue
+		}
+		err := r.ensure(o, s)
+		if _, unresolved := err.(*unresolvedError); unresolved {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decorate returns the value to inject for an object into a field or
+// parameter of type t, wrapped by any enabled decorators of t.
+func (r *Registry) decorate(o *Object, t reflect.Type, s *scope) (reflect.Value, error) {
+	if t.Kind() != reflect.Interface {
+		return o.reflectValue, nil
+	}
+	var decorators []*Object
+	for _, d := range s.objects {
+		if d != o && s.enabled[d] && d.decorates() == t {
+			decorators = append(decorators, d)
+		}
+	}
+	if len(decorators) == 0 {
+		return o.reflectValue, nil
+	}
+	r.sortExtensions(nil, decorators)
+	var chain []string
+	for _, d := range decorators {
+		chain = append(chain, d.FQN())
+	}
+	key := decoration{iface: t, chain: strings.Join(chain, ",")}
+	if v, ok := o.decorations[key]; ok {
+		return v, nil
+	}
+	v := o.Value
+	for i := len(decorators) - 1; i >= 0; i-- {
+		v = decorators[i].Value.(Decorator).Decorate(v)
+		if v == nil || !reflect.TypeOf(v).Implements(t) {
+			return reflect.Value{}, fmt.Errorf("decorator %s returned %T for %s, which does not implement %s",
+				decorators[i].FQN(), v, o.FQN(), t)
+		}
+	}
+	if o.decorations == nil {
+		o.decorations = make(map[decoration]reflect.Value)
+	}
+	o.decorations[key] = reflect.ValueOf(v)
+	return o.decorations[key], nil
+}
+
+// decorateAll returns the values to inject for objects into an extension
+// point or parameter with element type t.
+func (r *Registry) decorateAll(objects []*Object, t reflect.Type, s *scope) ([]reflect.Value, error) {
+	values := make([]reflect.Value, len(objects))
+	for i, o := range objects {
+		v, err := r.decorate(o, t, s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
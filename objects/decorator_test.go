@@ -0,0 +1,69 @@
+package objects
+
+import "testing"
+
+type Prefixer struct {
+	prefix   string
+	priority int
+}
+
+func (p *Prefixer) Decorates() interface{} {
+	return (*Stringer)(nil)
+}
+
+func (p *Prefixer) Decorate(v interface{}) interface{} {
+	return &Foo{p.prefix + v.(Stringer).String()}
+}
+
+func (p *Prefixer) Priority() int {
+	return p.priority
+}
+
+func TestDecorators(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A Stringer            `com:"singleton"`
+		B []Stringer          `com:"extpoint"`
+		C map[string]Stringer `com:"extpoint"`
+		D *Foo                `com:"singleton"`
+	}
+	foo := &Foo{"foo"}
+	err := r.Register(&Object{Value: foo, Name: "foo"}, &Object{Value: &v, Name: "v"},
+		&Object{Value: &Prefixer{"inner:", 1}, Name: "inner"},
+		&Object{Value: &Prefixer{"outer:", 2}, Name: "outer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "outer:inner:foo"
+	if v.A == nil || v.A.String() != want {
+		t.Fatalf("got %v; want %q", v.A, want)
+	}
+	if len(v.B) != 1 || v.B[0] != v.A || v.C["foo"] != v.A {
+		t.Fatal("extpoints not populated with decorated object")
+	}
+	if v.D != foo {
+		t.Fatal("concrete field was decorated")
+	}
+	obj, _ := r.Lookup("v")
+	edges := r.Graph().Dependencies(obj)
+	if len(edges) != 4 || edges[0].To.Value != foo {
+		t.Fatalf("got %#v; want edges to decorated object", edges)
+	}
+
+	decorated := v.A
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v.B[0] != decorated {
+		t.Fatal("object decorated again on reload")
+	}
+
+	outer, _ := r.Lookup("outer")
+	r.SetEnabled(outer.FQN(), false)
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "inner:foo" || v.B[0].String() != "inner:foo" {
+		t.Fatalf("got %q; want decorator chain without disabled decorator", v.A.String())
+	}
+}
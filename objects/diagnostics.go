@@ -0,0 +1,73 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// tagOptions lists the options allowed for each kind of com tag.
+var tagOptions = map[string][]string{
+	TagSingleton: {OptRequired, OptOptional, OptName, OptFQN},
+	TagConfig:    {OptRequired, OptOptional},
+	TagExtpoint:  {OptKey},
+	TagNested:    {},
+}
+
+// tagProblems returns descriptions of any misuse of a com tag on a field.
+func tagProblems(kind string, options map[string]string, field reflect.Value) []string {
+	allowed, known := tagOptions[kind]
+	if !known {
+		return []string{fmt.Sprintf("unknown com tag %q", kind)}
+	}
+	var problems []string
+	if !field.CanSet() {
+		problems = append(problems, "tagged field must be exported to be set")
+	}
+	var names []string
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !contains(allowed, name) {
+			problems = append(problems, fmt.Sprintf("unknown option %q for %s tag", name, kind))
+		}
+	}
+	t := field.Type()
+	switch kind {
+	case TagSingleton, TagConfig:
+		if t.Kind() != reflect.Interface && t.Kind() != reflect.Ptr {
+			problems = append(problems, fmt.Sprintf("%s field must be an interface or pointer", kind))
+		}
+		_, hasName := options[OptName]
+		_, hasFQN := options[OptFQN]
+		if hasName && hasFQN {
+			problems = append(problems, "only one of name and fqn options can be used")
+		}
+	case TagExtpoint:
+		switch {
+		case t.Kind() == reflect.Map && t.Key().Kind() != reflect.String:
+			problems = append(problems, "map extpoint must have string keys")
+		case t.Kind() != reflect.Slice && t.Kind() != reflect.Map:
+			problems = append(problems, "extpoint field must be a slice or map")
+		}
+		if key, ok := options[OptKey]; ok && key != "name" && key != "fqn" {
+			problems = append(problems, fmt.Sprintf("extpoint key must be name or fqn, not %q", key))
+		}
+	case TagNested:
+		if t.Kind() != reflect.Struct && !isStructPtr(t) {
+			problems = append(problems, "nested field must be a struct or struct pointer")
+		}
+	}
+	return problems
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
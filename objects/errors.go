@@ -0,0 +1,51 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Errors is a collection of errors that is returned as a single error when
+// an operation continues past individual failures.
+type Errors []error
+
+// Error joins the messages of all collected errors.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// errOrNil returns nil if no errors were collected so callers don't return
+// a non-nil error interface holding an empty collection.
+func (e Errors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// add appends an error to the collection unless it is nil, flattening
+// collections so they aren't nested.
+func (e Errors) add(err error) Errors {
+	if errs, ok := err.(Errors); ok {
+		return append(e, errs...)
+	}
+	if err != nil {
+		return append(e, err)
+	}
+	return e
+}
+
+// FieldError describes a problem with a tagged field of a registered object.
+type FieldError struct {
+	Object  string
+	Field   string
+	Problem string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: field %s: %s", e.Object, e.Field, e.Problem)
+}
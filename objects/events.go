@@ -0,0 +1,158 @@
+package objects
+
+import "reflect"
+
+// EventType is the kind of change to a registry an Event describes.
+type EventType string
+
+const (
+	// EventRegistered is sent for each object added to the registry.
+	EventRegistered EventType = "registered"
+
+	// EventUnregistered is sent for an object removed from the registry.
+	EventUnregistered EventType = "unregistered"
+
+	// EventEnabled is sent when a disabled object is enabled.
+	EventEnabled EventType = "enabled"
+
+	// EventDisabled is sent when an enabled object is disabled.
+	EventDisabled EventType = "disabled"
+
+	// EventRewired is sent for each tagged field whose assigned objects
+	// changed during a reload.
+	EventRewired EventType = "rewired"
+
+	// EventReloaded is sent after the registry has populated fields.
+	EventReloaded EventType = "reloaded"
+
+	// EventDeprecatedName is sent when an object is looked up by one of its
+	// aliases, so apps can warn that the name should be updated.
+	EventDeprecatedName EventType = "deprecated"
+)
+
+// Event describes a change to a registry. Object is a snapshot from when the
+// event happened. It is not set for EventReloaded, Field is only set for
+// EventRewired, and Name is only set to the alias used for
+// EventDeprecatedName.
+type Event struct {
+	Type   EventType
+	Object *Object
+	Field  string
+	Name   string
+}
+
+// Observer is an extension point interface for objects that need to know
+// about changes to the registry, for example to rebuild state after a reload.
+type Observer interface {
+	// ObserveEvent is called on enabled objects for every event after the
+	// change has been made. The registry is not locked during the call.
+	ObserveEvent(e Event)
+}
+
+type subscription struct {
+	id int
+	fn func(Event)
+}
+
+// Subscribe registers a function to be called for every event in the order
+// they happen. Like Observer, the registry is not locked during the call,
+// which is made from the goroutine that changed the registry, so calls can be
+// concurrent. It returns a function to stop the subscription.
+func (r *Registry) Subscribe(fn func(Event)) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastID++
+	id := r.lastID
+	r.subscriptions = append(r.subscriptions, subscription{id, fn})
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, s := range r.subscriptions {
+			if s.id == id {
+				r.subscriptions = append(r.subscriptions[:i:i], r.subscriptions[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// emit queues an event to be dispatched once the registry is unlocked.
+func (r *Registry) emit(t EventType, o *Object, field string) {
+	if o != nil {
+		o = o.snapshot()
+	}
+	r.pending = append(r.pending, Event{Type: t, Object: o, Field: field})
+}
+
+// dispatch sends queued events to subscribers and observers. It is deferred
+// before unlocking in methods that emit events so it runs after the unlock.
+func (r *Registry) dispatch() {
+	r.mu.Lock()
+	events := r.pending
+	r.pending = nil
+	subscriptions := append([]subscription{}, r.subscriptions...)
+	var observers []Observer
+	for _, o := range r.objects {
+		if observer, ok := o.Value.(Observer); ok && o.Enabled {
+			observers = append(observers, observer)
+		}
+	}
+	r.mu.Unlock()
+	for _, e := range events {
+		for _, s := range subscriptions {
+			s.fn(e)
+		}
+		for _, observer := range observers {
+			observer.ObserveEvent(e)
+		}
+	}
+}
+
+// wiring captures the identity of values assigned to every tagged field so
+// changes can be detected after a reload.
+func (r *Registry) wiring() map[*Field][]uintptr {
+	w := make(map[*Field][]uintptr)
+	for _, o := range r.objects {
+		for _, f := range o.Fields {
+			var ptrs []uintptr
+			for _, v := range f.values() {
+				if v.Kind() == reflect.Interface {
+					v = v.Elem()
+				}
+				if v.IsValid() && v.Kind() == reflect.Ptr {
+					ptrs = append(ptrs, v.Pointer())
+				} else {
+					ptrs = append(ptrs, 0)
+				}
+			}
+			w[f] = ptrs
+		}
+	}
+	return w
+}
+
+// emitRewired emits EventRewired for fields whose wiring changed.
+func (r *Registry) emitRewired(before map[*Field][]uintptr) {
+	after := r.wiring()
+	for _, o := range r.objects {
+		for _, name := range o.fieldNames() {
+			f := o.Fields[name]
+			prev, existed := before[f]
+			if existed && !equalPointers(prev, after[f]) {
+				r.emit(EventRewired, o, name)
+			}
+		}
+	}
+}
+
+func equalPointers(a, b []uintptr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
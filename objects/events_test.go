@@ -0,0 +1,64 @@
+package objects
+
+import "testing"
+
+type observer struct {
+	events []Event
+}
+
+func (o *observer) ObserveEvent(e Event) {
+	o.events = append(o.events, e)
+}
+
+func eventTypes(events []Event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, string(e.Type))
+	}
+	return types
+}
+
+func TestSubscribe(t *testing.T) {
+	r := &Registry{}
+	var events []Event
+	unsubscribe := r.Subscribe(func(e Event) {
+		events = append(events, e)
+		// the registry must not be locked during dispatch
+		r.Objects()
+	})
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	foo := &Object{Value: &Foo{t.Name()}}
+	if err := r.Register(foo); err != nil {
+		t.Fatal(err)
+	}
+	r.SetEnabled(foo.FQN(), false)
+	unsubscribe()
+	r.SetEnabled(foo.FQN(), true)
+	want := []string{"registered", "reloaded", "registered", "rewired", "reloaded", "disabled"}
+	if got := eventTypes(events); !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+	if events[3].Field != "A" {
+		t.Fatalf("got %#v; want rewired event for field A", events[3])
+	}
+}
+
+func TestObserver(t *testing.T) {
+	r := &Registry{}
+	obs := &observer{}
+	if err := r.Register(&Object{Value: obs}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"registered", "reloaded", "reloaded"}
+	if got := eventTypes(obs.events); !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package objects
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Wiring is a stable description of registered objects and what is assigned
+// to their tagged fields. Objects are sorted by FQN and fields by name, so the
+// JSON encoding of a Wiring can be diffed between builds.
+type Wiring struct {
+	Objects []WiredObject `json:"objects"`
+}
+
+// WiredObject describes a registered object in a Wiring.
+type WiredObject struct {
+	FQN     string       `json:"fqn"`
+	Name    string       `json:"name"`
+	PkgPath string       `json:"pkgPath"`
+	Enabled bool         `json:"enabled"`
+	Fields  []WiredField `json:"fields,omitempty"`
+}
+
+// WiredField describes a tagged field in a Wiring. Targets are the FQNs of
+// registered objects assigned to the field, in extpoint order for extpoints.
+type WiredField struct {
+	Name    string   `json:"name"`
+	Kind    EdgeKind `json:"kind"`
+	Targets []string `json:"targets"`
+}
+
+// Wiring returns a description of all registered objects and their fields.
+func (r *Registry) Wiring() *Wiring {
+	g := r.Graph()
+	w := &Wiring{Objects: []WiredObject{}}
+	for _, o := range g.Nodes {
+		wo := WiredObject{
+			FQN:     o.FQN(),
+			Name:    o.Name,
+			PkgPath: o.PkgPath,
+			Enabled: o.Enabled,
+		}
+		edges := g.Dependencies(o)
+		for _, name := range o.fieldNames() {
+			wf := WiredField{
+				Name:    name,
+				Kind:    o.Fields[name].kind(),
+				Targets: []string{},
+			}
+			for _, e := range edges {
+				if e.Field == name {
+					wf.Targets = append(wf.Targets, e.To.FQN())
+				}
+			}
+			wo.Fields = append(wo.Fields, wf)
+		}
+		w.Objects = append(w.Objects, wo)
+	}
+	sort.SliceStable(w.Objects, func(i, j int) bool {
+		return w.Objects[i].FQN < w.Objects[j].FQN
+	})
+	return w
+}
+
+// WriteJSON writes the wiring as an indented JSON document.
+func (w *Wiring) WriteJSON(out io.Writer) error {
+	b, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", b)
+	return err
+}
+
+// WriteDOT writes the wiring as a Graphviz digraph. Each object is a node
+// labeled with its name and package path, drawn dashed when disabled, and
+// each field assignment is an edge labeled with the field name and kind.
+func (w *Wiring) WriteDOT(out io.Writer) error {
+	if _, err := fmt.Fprintln(out, "digraph com {"); err != nil {
+		return err
+	}
+	for _, o := range w.Objects {
+		style := "solid"
+		if !o.Enabled {
+			style = "dashed"
+		}
+		label := fmt.Sprintf("%s\n%s", o.Name, o.PkgPath)
+		if _, err := fmt.Fprintf(out, "\t%q [label=%q, style=%s];\n", o.FQN, label, style); err != nil {
+			return err
+		}
+	}
+	for _, o := range w.Objects {
+		for _, f := range o.Fields {
+			for _, target := range f.Targets {
+				label := fmt.Sprintf("%s (%s)", f.Name, f.Kind)
+				if _, err := fmt.Fprintf(out, "\t%q -> %q [label=%q];\n", o.FQN, target, label); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	_, err := fmt.Fprintln(out, "}")
+	return err
+}
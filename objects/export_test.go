@@ -0,0 +1,61 @@
+package objects
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWiring(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo       `com:"singleton"`
+		B []Stringer `com:"extpoint"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}, &Object{Value: &Foo{"foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	w := r.Wiring()
+	if len(w.Objects) != 2 {
+		t.Fatalf("got %d objects; want 2", len(w.Objects))
+	}
+	// anonymous struct types have no package path so sort first
+	if w.Objects[0].Name != "v" {
+		t.Fatalf("got %#v; want objects sorted by FQN", w.Objects[0].Name)
+	}
+	fields := w.Objects[0].Fields
+	if len(fields) != 2 || len(fields[0].Targets) != 1 || fields[0].Targets[0] != w.Objects[1].FQN {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+
+	var buf bytes.Buffer
+	if err := w.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Wiring
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Objects) != 2 {
+		t.Fatal("json wiring did not round trip")
+	}
+}
+
+func TestWiringDOT(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}, &Object{Value: &Foo{"foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.Wiring().WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `"#v" -> "github.com/gliderlabs/com/objects#foo" [label="A (singleton)"];`
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("got %s; want edge %s", buf.String(), want)
+	}
+}
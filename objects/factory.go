@@ -0,0 +1,231 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterFactory adds an object to the registry that is constructed by a
+// function the first time it is needed, either to populate a field of another
+// object or when returned by Lookup. The function must return a pointer to a
+// struct, optionally followed by an error. Its parameters are resolved from the
+// registry like fields: a slice parameter is passed all enabled objects
+// assignable to its element type and any other parameter is passed the only
+// enabled object assignable to it. Construction waits until all parameters
+// can be resolved, and errors returned by the function are returned from the
+// reload that needed the object.
+//
+// Until it is constructed, the object has a nil Value and no Fields, but its
+// name and type are known from the function's return type so it can be looked
+// up and matched against fields. Disabled objects are never constructed by
+// reloading. As with Register, the registry is restored if populating fields
+// with the new object fails.
+func (r *Registry) RegisterFactory(fn interface{}, name string) error {
+	return r.registerFactory(fn, name, false)
+}
+
+// RegisterConstructor adds an object to the registry whose value is returned
+// by a constructor function, which lets components receive their dependencies
+// as parameters and keep them in unexported fields. The function and its
+// parameters follow the same rules as RegisterFactory, but instead of waiting
+// until the object is needed, it is called by the first reload where all of its
+// parameters can be resolved. Slice parameters only receive the objects that
+// are enabled at that time. Reload returns an error for enabled objects whose
+// constructor still can't be called.
+func (r *Registry) RegisterConstructor(fn interface{}, name string) error {
+	return r.registerFactory(fn, name, true)
+}
+
+// registerFactory adds an object constructed by a function, either when it is
+// needed or, if eager, as soon as its parameters can be resolved.
+func (r *Registry) registerFactory(fn interface{}, name string, eager bool) error {
+	o, err := newFactory(fn, name)
+	if err != nil {
+		return err
+	}
+	o.eager = eager
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	r.initDisabled()
+	saved, pending := r.snapshot(), len(r.pending)
+	o.Enabled = !r.isDisabled(o.FQN())
+	r.objects = append(r.objects, o)
+	r.emit(EventRegistered, o, "")
+	if err := r.reload(); err != nil {
+		r.restore(saved)
+		r.pending = r.pending[:pending]
+		return err
+	}
+	return nil
+}
+
+func newFactory(fn interface{}, name string) (*Object, error) {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		return nil, errors.New("factory must be a function")
+	}
+	ft := fv.Type()
+	if ft.IsVariadic() {
+		return nil, errors.New("factory must not be variadic")
+	}
+	if ft.NumOut() < 1 || ft.NumOut() > 2 || !isStructPtr(ft.Out(0)) {
+		return nil, errors.New("factory must return a struct pointer and optionally an error")
+	}
+	if ft.NumOut() == 2 && ft.Out(1) != errorType {
+		return nil, errors.New("factory must return a struct pointer and optionally an error")
+	}
+	o := &Object{
+		Name:        name,
+		Fields:      make(map[string]*Field),
+		factory:     fv,
+		reflectType: ft.Out(0),
+	}
+	return o, o.setNames()
+}
+
+// lazy returns true if the object has a factory that has not been called.
+func (o *Object) lazy() bool {
+	return o.factory.IsValid() && o.Value == nil
+}
+
+// unresolvedError is returned when a factory can't be called yet because a
+// parameter can't be resolved from the registry.
+type unresolvedError struct {
+	Object string
+	Param  reflect.Type
+}
+
+func (e *unresolvedError) Error() string {
+	return fmt.Sprintf("unable to construct %s: no object to pass as %s", e.Object, e.Param)
+}
+
+// ensure constructs a lazy object. Objects inherited from a parent registry
+// are constructed by the parent, and the registry's copy updated.
+func (r *Registry) ensure(o *Object, s *scope) error {
+	if !o.lazy() {
+		return nil
+	}
+	for _, own := range r.objects {
+		if own == o {
+			return r.construct(o, s)
+		}
+	}
+	if r.parent == nil {
+		return nil
+	}
+	constructed, err := r.parent.Lookup(o.FQN())
+	if err != nil {
+		return err
+	}
+	o.update(constructed)
+	return nil
+}
+
+// construct calls the factory of a lazy object owned by the registry and sets
+// up the returned value.
+func (r *Registry) construct(o *Object, s *scope) error {
+	if o.constructing {
+		return fmt.Errorf("factory for %s depends on itself", o.FQN())
+	}
+	o.constructing = true
+	defer func() {
+		o.constructing = false
+	}()
+	args, err := r.resolveArgs(o, s)
+	if err != nil {
+		return err
+	}
+	out := o.factory.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return fmt.Errorf("factory for %s: %v", o.FQN(), out[1].Interface())
+	}
+	if out[0].IsNil() {
+		return fmt.Errorf("factory for %s returned nil", o.FQN())
+	}
+	o.Value = out[0].Interface()
+	o.reflectValue = out[0]
+	o.addAliases()
+	r.constructed = true
+	return o.collectFields()
+}
+
+// resolveArgs resolves the parameters of a factory from the registry.
+func (r *Registry) resolveArgs(o *Object, s *scope) ([]reflect.Value, error) {
+	ft := o.factory.Type()
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		param := ft.In(i)
+		if param.Kind() == reflect.Slice {
+			objects, err := r.ensureAll(s.assignable(param.Elem(), false), s)
+			if err != nil {
+				return nil, err
+			}
+			r.sortExtensions(nil, objects)
+			values, err := r.decorateAll(objects, param.Elem(), s)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = reflect.MakeSlice(param, 0, len(objects))
+			for _, v := range values {
+				args[i] = reflect.Append(args[i], v)
+			}
+			continue
+		}
+		candidates := s.assignable(param, true)
+		if len(candidates) > 1 {
+			return nil, fmt.Errorf("factory for %s: ambiguous objects to pass as %s", o.FQN(), param)
+		}
+		if len(candidates) == 0 {
+			return nil, &unresolvedError{Object: o.FQN(), Param: param}
+		}
+		if err := r.ensure(candidates[0], s); err != nil {
+			return nil, err
+		}
+		v, err := r.decorate(candidates[0], param, s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// constructEager calls the constructors of enabled objects registered with
+// RegisterConstructor whose parameters can be resolved.
+func (r *Registry) constructEager(s *scope) error {
+	for _, o := range r.objects {
+		if !o.eager || !o.Enabled || !o.lazy() {
+			continue
+		}
+		err := r.construct(o, s)
+		if _, unresolved := err.(*unresolvedError); unresolved {
+			o.unresolved = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureAll constructs any lazy objects, leaving out those that can't be
+// constructed yet.
+func (r *Registry) ensureAll(objects []*Object, s *scope) ([]*Object, error) {
+	var ensured []*Object
+	for _, obj := range objects {
+		err := r.ensure(obj, s)
+		if _, unresolved := err.(*unresolvedError); unresolved {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ensured = append(ensured, obj)
+	}
+	return ensured, nil
+}
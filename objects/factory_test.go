@@ -0,0 +1,130 @@
+package objects
+
+import (
+	"errors"
+	"testing"
+)
+
+type Pool struct {
+	Foo  *Foo
+	Exts []Stringer
+}
+
+func TestFactoryLazy(t *testing.T) {
+	r := &Registry{}
+	calls := 0
+	newPool := func(foo *Foo, exts []Stringer) (*Pool, error) {
+		calls++
+		return &Pool{Foo: foo, Exts: exts}, nil
+	}
+	foo := &Foo{t.Name()}
+	if err := r.Register(&Object{Value: foo}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterFactory(newPool, ""); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatal("factory called before object was needed")
+	}
+	var v struct {
+		P *Pool `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || v.P == nil || v.P.Foo != foo || len(v.P.Exts) != 1 {
+		t.Fatalf("factory not called with resolved arguments: %#v", v.P)
+	}
+	obj, err := r.Lookup("Pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Value != v.P || calls != 1 {
+		t.Fatal("factory object constructed more than once")
+	}
+}
+
+func TestFactoryLookup(t *testing.T) {
+	r := &Registry{}
+	if err := r.RegisterFactory(func() *Foo { return &Foo{"lazy"} }, "lazy"); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.Lookup("lazy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Value.(*Foo).String() != "lazy" {
+		t.Fatal("lookup did not construct factory object")
+	}
+}
+
+func TestFactoryLookupDisabled(t *testing.T) {
+	r := &Registry{}
+	if err := r.RegisterFactory(func() *Foo { return &Foo{"lazy"} }, "lazy"); err != nil {
+		t.Fatal(err)
+	}
+	r.SetEnabled(r.Objects()[0].FQN(), false)
+	obj, err := r.Lookup("lazy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Value != nil {
+		t.Fatal("lookup constructed disabled factory object")
+	}
+}
+
+func TestFactoryError(t *testing.T) {
+	r := &Registry{}
+	factoryErr := errors.New("failed")
+	if err := r.RegisterFactory(func() (*Foo, error) { return nil, factoryErr }, ""); err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err == nil {
+		t.Fatal("expected factory error")
+	}
+}
+
+func TestFactoryInvalid(t *testing.T) {
+	r := &Registry{}
+	if err := r.RegisterFactory(func() Stringer { return nil }, ""); err == nil {
+		t.Fatal("expected error for factory not returning struct pointer")
+	}
+}
+
+type Handler struct {
+	foo  *Foo
+	exts []Stringer
+}
+
+func NewHandler(foo *Foo, exts []Stringer) *Handler {
+	return &Handler{foo: foo, exts: exts}
+}
+
+func TestConstructor(t *testing.T) {
+	r := &Registry{}
+	if err := r.RegisterConstructor(NewHandler, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected error for constructor with unresolved parameter")
+	}
+	foo := &Foo{t.Name()}
+	if err := r.Register(&Object{Value: foo}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.Lookup("Handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := obj.Value.(*Handler)
+	if h.foo != foo || len(h.exts) != 1 {
+		t.Fatalf("constructor not called with resolved parameters: %#v", h)
+	}
+}
@@ -0,0 +1,102 @@
+package objects
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Find returns snapshots of all objects matching a glob pattern, including
+// those inherited from parent registries. The pattern is matched case
+// insensitively against object FQNs, as in "github.com/acme/*#*handler".
+// It uses the syntax of path.Match, except that "*" also matches slashes, so
+// "github.com/acme/*" matches objects of every package under github.com/acme
+// and "*handler" matches objects named like that in any package. Patterns
+// without "#" are also matched against package paths. The only possible error
+// is path.ErrBadPattern.
+func (r *Registry) Find(pattern string) ([]*Object, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(pattern)
+}
+
+// SetEnabledMatching sets whether all objects currently matching a pattern,
+// as used by Find, are enabled.
+func (r *Registry) SetEnabledMatching(pattern string, enabled bool) error {
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	matches, err := r.find(pattern)
+	if err != nil {
+		return err
+	}
+	for _, o := range matches {
+		r.setEnabled(o.FQN(), enabled)
+	}
+	return nil
+}
+
+// IsPattern returns true if a name has glob characters and should be used
+// with Find rather than Lookup.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// find returns snapshots of objects matching a pattern. It only needs the
+// registry to be read locked.
+func (r *Registry) find(pattern string) ([]*Object, error) {
+	re, err := compileGlob(strings.ToLower(pattern))
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Object
+	for _, o := range r.collect(true).objects {
+		if re.MatchString(o.FQN()) ||
+			!strings.Contains(pattern, "#") && re.MatchString(strings.ToLower(o.PkgPath)) {
+			matches = append(matches, o)
+		}
+	}
+	return matches, nil
+}
+
+// compileGlob converts a glob pattern to an anchored regular expression.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var b bytes.Buffer
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			i++
+			if i == len(pattern) {
+				return nil, path.ErrBadPattern
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, path.ErrBadPattern
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, path.ErrBadPattern
+	}
+	return re, nil
+}
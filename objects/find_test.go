@@ -0,0 +1,52 @@
+package objects
+
+import (
+	"path"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	r := &Registry{}
+	err := r.Register(&Object{Value: &Foo{"a"}, Name: "aHandler"},
+		&Object{Value: &Foo{"b"}, Name: "bHandler"},
+		&Object{Value: &Foo{"c"}, Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pattern, want := range map[string]int{
+		"github.com/gliderlabs/com/*#*handler": 2,
+		"*/objects#other":                      1,
+		"github.com/gliderlabs/com/*":          3,
+		"github.com/gliderlabs/com/objects":    3,
+		"github.com/other/*":                   0,
+		"*#[ab]handler":                        2,
+	} {
+		found, err := r.Find(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != want {
+			t.Fatalf("got %d objects for %q; want %d", len(found), pattern, want)
+		}
+	}
+	if _, err := r.Find("[bad"); err != path.ErrBadPattern {
+		t.Fatalf("got %#v; want ErrBadPattern", err)
+	}
+}
+
+func TestSetEnabledMatching(t *testing.T) {
+	r := &Registry{}
+	err := r.Register(&Object{Value: &Foo{"a"}, Name: "aHandler"},
+		&Object{Value: &Foo{"b"}, Name: "bHandler"},
+		&Object{Value: &Foo{"c"}, Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetEnabledMatching("*#*HANDLER", false); err != nil {
+		t.Fatal(err)
+	}
+	enabled := r.Enabled()
+	if len(enabled) != 1 || enabled[0].Name != "other" {
+		t.Fatalf("got %d enabled; want only other", len(enabled))
+	}
+}
@@ -0,0 +1,50 @@
+//go:build go1.18
+// +build go1.18
+
+package objects
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Get returns the value of the only enabled object in the registry assignable
+// to T, following the same rules as singleton fields. It returns an error
+// wrapping ErrNotFound or ErrAmbiguous if there isn't exactly one.
+func Get[T any](r *Registry) (T, error) {
+	var zero T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	objects, err := r.resolve(t, false)
+	if err != nil {
+		return zero, err
+	}
+	switch len(objects) {
+	case 0:
+		return zero, fmt.Errorf("get %s: %w", t, ErrNotFound)
+	case 1:
+		return objects[0].Value.(T), nil
+	default:
+		return zero, fmt.Errorf("get %s: %w", t, ErrAmbiguous)
+	}
+}
+
+// MustGet is like Get but panics if there isn't exactly one object.
+func MustGet[T any](r *Registry) T {
+	v, err := Get[T](r)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// All returns the values of all enabled objects in the registry assignable
+// to T, ordered the same as extension points. Objects that fail to construct
+// are left out.
+func All[T any](r *Registry) []T {
+	objects, _ := r.resolve(reflect.TypeOf((*T)(nil)).Elem(), true)
+	values := make([]T, 0, len(objects))
+	for _, obj := range objects {
+		values = append(values, obj.Value.(T))
+	}
+	return values
+}
@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+package objects
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	r := &Registry{}
+	foo := &Foo{t.Name()}
+	if err := r.Register(&Object{Value: foo}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Get[Stringer](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != foo {
+		t.Fatalf("got %#v; want %#v", got, foo)
+	}
+	if _, err := Get[*Pool](r); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %#v; want ErrNotFound", err)
+	}
+	if err := r.Register(&Object{Value: &Foo{"other"}, Name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get[Stringer](r); !errors.Is(err, ErrAmbiguous) {
+		t.Fatalf("got %#v; want ErrAmbiguous", err)
+	}
+}
+
+func TestMustGetPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	MustGet[Stringer](&Registry{})
+}
+
+func TestAll(t *testing.T) {
+	r := &Registry{}
+	b := &Object{Value: &Foo{"b"}, Name: "b"}
+	a := &Object{Value: &Foo{"a"}, Name: "a"}
+	if err := r.Register(b, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterFactory(func() *Foo { return &Foo{"c"} }, "c"); err != nil {
+		t.Fatal(err)
+	}
+	r.SetEnabled(b.FQN(), false)
+	got := All[Stringer](r)
+	if len(got) != 2 || got[0].String() != "a" || got[1].String() != "c" {
+		t.Fatalf("got %#v; want enabled objects a and c", got)
+	}
+}
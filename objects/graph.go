@@ -0,0 +1,219 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EdgeKind is the kind of field an object depends on another object through.
+type EdgeKind string
+
+const (
+	EdgeSingleton EdgeKind = TagSingleton
+	EdgeExtpoint  EdgeKind = TagExtpoint
+	EdgeConfig    EdgeKind = TagConfig
+)
+
+// Edge represents an object depending on another object because it is
+// assigned to one of its fields.
+type Edge struct {
+	From  *Object
+	To    *Object
+	Field string
+	Kind  EdgeKind
+}
+
+// Graph is the dependency graph of objects in a registry as currently wired.
+type Graph struct {
+	Nodes []*Object
+	Edges []Edge
+}
+
+// CycleError is returned when objects depend on each other in a cycle and an
+// order of objects is needed.
+type CycleError struct {
+	// Path starts and ends with the same object.
+	Path []*Object
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, len(e.Path))
+	for i, o := range e.Path {
+		names[i] = o.FQN()
+	}
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(names, " -> "))
+}
+
+// Graph builds the dependency graph of all registered objects based on
+// what is currently assigned to their tagged fields. Nodes and edges refer to
+// snapshots of the objects.
+func (r *Registry) Graph() *Graph {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	g := r.graph(func(*Object) bool { return true })
+	snapshots := make(map[*Object]*Object)
+	for i, o := range g.Nodes {
+		snapshots[o] = o.snapshot()
+		g.Nodes[i] = snapshots[o]
+	}
+	for i, e := range g.Edges {
+		g.Edges[i].From = snapshots[e.From]
+		g.Edges[i].To = snapshots[e.To]
+	}
+	return g
+}
+
+func (r *Registry) graph(include func(*Object) bool) *Graph {
+	g := &Graph{}
+	for _, o := range r.objects {
+		if include(o) {
+			g.Nodes = append(g.Nodes, o)
+		}
+	}
+	for _, o := range g.Nodes {
+		for _, name := range o.fieldNames() {
+			f := o.Fields[name]
+			for _, v := range f.values() {
+				for _, to := range g.Nodes {
+					if to.is(v) {
+						g.Edges = append(g.Edges, Edge{
+							From:  o,
+							To:    to,
+							Field: name,
+							Kind:  f.kind(),
+						})
+					}
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Dependencies returns the edges from an object to the objects it depends on.
+// The object can be registered or a snapshot.
+func (g *Graph) Dependencies(o *Object) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if e.From.registered() == o.registered() {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Dependents returns the edges to an object from the objects that depend on it.
+// The object can be registered or a snapshot.
+func (g *Graph) Dependents(o *Object) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if e.To.registered() == o.registered() {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Sort returns the nodes ordered so that every object comes after the objects
+// it depends on, otherwise keeping the order of Nodes. Objects assigned to
+// their own fields are ignored, but any other cycle results in a CycleError.
+func (g *Graph) Sort() ([]*Object, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var order []*Object
+	var path []*Object
+	state := make(map[*Object]int)
+	var visit func(o *Object) error
+	visit = func(o *Object) error {
+		switch state[o] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == o {
+					cycle := append([]*Object{}, path[i:]...)
+					return &CycleError{Path: append(cycle, o)}
+				}
+			}
+		}
+		state[o] = visiting
+		path = append(path, o)
+		for _, e := range g.Dependencies(o) {
+			if e.To == o {
+				continue
+			}
+			if err := visit(e.To); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[o] = visited
+		order = append(order, o)
+		return nil
+	}
+	for _, o := range g.Nodes {
+		if err := visit(o); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// values returns the values currently in a field. Extension point slices
+// are returned in order and maps by sorted key.
+func (f *Field) values() []reflect.Value {
+	v := f.reflectValue
+	if !f.Extpoint {
+		return []reflect.Value{v}
+	}
+	var values []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			values = append(values, v.MapIndex(k))
+		}
+	}
+	return values
+}
+
+// kind returns the kind of edge from an object through this field.
+func (f *Field) kind() EdgeKind {
+	switch {
+	case f.Extpoint:
+		return EdgeExtpoint
+	case f.Config:
+		return EdgeConfig
+	default:
+		return EdgeSingleton
+	}
+}
+
+// is returns true if the value, which may be an interface, holds this
+// object's value, either as is or decorated.
+func (o *Object) is(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() || !o.reflectValue.IsValid() {
+		return false
+	}
+	for _, d := range o.decorations {
+		if d.Kind() == reflect.Ptr && v.Type() == d.Type() && v.Pointer() == d.Pointer() {
+			return true
+		}
+	}
+	return v.Type() == o.reflectType && v.Pointer() == o.reflectValue.Pointer()
+}
@@ -0,0 +1,56 @@
+package objects
+
+import (
+	"context"
+	"testing"
+)
+
+type Chicken struct {
+	Egg *Egg `com:"singleton"`
+}
+
+type Egg struct {
+	Chicken *Chicken `com:"singleton"`
+}
+
+func TestGraphEdges(t *testing.T) {
+	r := &Registry{}
+	ext := &Foo{"ext"}
+	var v struct {
+		A *Foo       `com:"singleton"`
+		B []Stringer `com:"extpoint"`
+		C Stringer   `com:"config"`
+	}
+	obj := &Object{Value: &v, Name: "v"}
+	if err := r.Register(obj, &Object{Value: ext}); err != nil {
+		t.Fatal(err)
+	}
+	edges := r.Graph().Dependencies(obj)
+	if len(edges) != 2 {
+		t.Fatalf("got %d edges; want 2", len(edges))
+	}
+	if edges[0].Field != "A" || edges[0].Kind != EdgeSingleton {
+		t.Fatalf("got %#v; want singleton edge for A", edges[0])
+	}
+	if edges[1].Field != "B" || edges[1].Kind != EdgeExtpoint {
+		t.Fatalf("got %#v; want extpoint edge for B", edges[1])
+	}
+}
+
+func TestGraphSortCycle(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Object{Value: &Chicken{}}, &Object{Value: &Egg{}}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.Graph().Sort()
+	cycle, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("got %#v; want CycleError", err)
+	}
+	if len(cycle.Path) != 3 || cycle.Path[0] != cycle.Path[2] {
+		t.Fatalf("unexpected cycle path: %s", cycle)
+	}
+	if err := r.Start(context.Background()); err == nil {
+		t.Fatal("expected cycle error from start")
+	}
+}
@@ -0,0 +1,110 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Starter is an extension point interface for objects that need to run
+// something when the application starts, such as opening connections or
+// starting servers.
+type Starter interface {
+	// Start is called on enabled objects by Registry.Start after all of the
+	// objects they depend on have been started.
+	Start(ctx context.Context) error
+}
+
+// Stopper is an extension point interface for objects that need to clean up
+// when the application stops.
+type Stopper interface {
+	// Stop is called on started objects by Registry.Stop before any of the
+	// objects they depend on have been stopped.
+	Stop(ctx context.Context) error
+}
+
+// Start calls Start on all enabled objects implementing Starter. Objects are
+// started in dependency order, so any object assigned to a singleton, config,
+// or extpoint field of another object is started before it. A CycleError is
+// returned if no such order exists. If StartTimeout is set, each object gets
+// its own deadline. If an object fails to start, the objects already started
+// are stopped in reverse order and all errors are returned together. Since ctx
+// may be done by then, they are stopped with a fresh context limited only by
+// StopTimeout.
+func (r *Registry) Start(ctx context.Context) error {
+	r.mu.RLock()
+	order, err := r.graph(func(o *Object) bool { return o.Enabled }).Sort()
+	for i, o := range order {
+		order[i] = o.snapshot()
+	}
+	r.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	var started []*Object
+	for _, o := range order {
+		starter, ok := o.Value.(Starter)
+		if !ok {
+			started = append(started, o)
+			continue
+		}
+		if err := runHook(ctx, r.StartTimeout, starter.Start); err != nil {
+			errs := Errors{fmt.Errorf("start %s: %v", o.FQN(), err)}
+			errs = append(errs, stopObjects(context.Background(), r.StopTimeout, started)...)
+			return errs
+		}
+		started = append(started, o)
+	}
+
+	r.mu.Lock()
+	r.started = started
+	r.mu.Unlock()
+	return nil
+}
+
+// Stop calls Stop on all objects implementing Stopper that were started by
+// Start, in the reverse order they were started. If StopTimeout is set, each
+// object gets its own deadline. Stopping continues past errors, which are all
+// returned together.
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	r.started = nil
+	r.mu.Unlock()
+	return stopObjects(ctx, r.StopTimeout, started).errOrNil()
+}
+
+func stopObjects(ctx context.Context, timeout time.Duration, started []*Object) Errors {
+	var errs Errors
+	for i := len(started) - 1; i >= 0; i-- {
+		stopper, ok := started[i].Value.(Stopper)
+		if !ok {
+			continue
+		}
+		if err := runHook(ctx, timeout, stopper.Stop); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %v", started[i].FQN(), err))
+		}
+	}
+	return errs
+}
+
+// runHook calls a lifecycle hook, giving up when the context is done even if
+// the hook has not returned.
+func runHook(ctx context.Context, timeout time.Duration, hook func(context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,120 @@
+package objects
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type lifecycleLog struct {
+	events []string
+}
+
+type Service struct {
+	log      *lifecycleLog
+	name     string
+	startErr error
+	block    bool
+}
+
+func (s *Service) Start(ctx context.Context) error {
+	if s.block {
+		<-make(chan struct{})
+	}
+	s.log.events = append(s.log.events, "start "+s.name)
+	return s.startErr
+}
+
+func (s *Service) Stop(ctx context.Context) error {
+	s.log.events = append(s.log.events, "stop "+s.name)
+	return ctx.Err()
+}
+
+type Server struct {
+	Service
+	DB *Service `com:"singleton"`
+}
+
+func TestStartStopOrder(t *testing.T) {
+	r := &Registry{}
+	log := &lifecycleLog{}
+	server := &Server{Service: Service{log: log, name: "server"}}
+	db := &Service{log: log, name: "db"}
+	if err := r.Register(&Object{Value: server}, &Object{Value: db}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start server", "stop server", "stop db"}
+	if got := log.events; !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestStartErrorStopsStarted(t *testing.T) {
+	r := &Registry{}
+	log := &lifecycleLog{}
+	startErr := errors.New("failed")
+	server := &Server{Service: Service{log: log, name: "server", startErr: startErr}}
+	db := &Service{log: log, name: "db"}
+	if err := r.Register(&Object{Value: server}, &Object{Value: db}); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Start(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := []string{"start db", "start server", "stop db"}
+	if got := log.events; !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestStartTimeout(t *testing.T) {
+	r := &Registry{StartTimeout: 10 * time.Millisecond}
+	db := &Service{log: &lifecycleLog{}, name: "db", block: true}
+	if err := r.Register(&Object{Value: db}); err != nil {
+		t.Fatal(err)
+	}
+	errs, ok := r.Start(context.Background()).(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got %#v; want one error", errs)
+	}
+}
+
+func TestStartExpiredStopsStarted(t *testing.T) {
+	r := &Registry{}
+	log := &lifecycleLog{}
+	server := &Server{Service: Service{log: log, name: "server", block: true}}
+	db := &Service{log: log, name: "db"}
+	if err := r.Register(&Object{Value: server}, &Object{Value: db}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	errs, ok := r.Start(ctx).(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got %#v; want only the start error", errs)
+	}
+	want := []string{"start db", "stop db"}
+	if got := log.events; !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -4,28 +4,104 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
 	TagSingleton = "singleton"
 	TagExtpoint  = "extpoint"
 	TagConfig    = "config"
+
+	// TagNested marks a struct or struct pointer field whose own tagged fields
+	// are populated. Embedded structs are walked without needing this tag.
+	TagNested = "nested"
+
+	// OptRequired marks a singleton or config field that must be assigned
+	// for Reload to succeed, for example `com:"singleton,required"`.
+	OptRequired = "required"
+
+	// OptOptional marks a singleton or config field that may be left
+	// unassigned when a Registry has RequireAll set.
+	OptOptional = "optional"
+
+	// OptName qualifies a singleton field with the name of the object to
+	// assign, resolved with Lookup, for example `com:"singleton,name=postgres"`.
+	OptName = "name"
+
+	// OptFQN qualifies a singleton field with the exact FQN of the object to
+	// assign.
+	OptFQN = "fqn"
+
+	// OptKey chooses what map extension point fields are keyed by. The
+	// default is the object Name, and `com:"extpoint,key=fqn"` keys by FQN.
+	OptKey = "key"
 )
 
-// Object represents an object and its metadata in a registry
+var (
+	// ErrNotFound is returned when no object matches a lookup.
+	ErrNotFound = errors.New("object not found")
+
+	// ErrAmbiguous is returned when more than one object matches a lookup.
+	ErrAmbiguous = errors.New("ambiguous name for lookup")
+)
+
+// Aliaser is an interface objects can implement to declare other names they
+// can be looked up by, such as names they had before being renamed.
+type Aliaser interface {
+	// Aliases returns the other names of the object. Using them to look up
+	// the object emits EventDeprecatedName.
+	Aliases() []string
+}
+
+// Object represents an object and its metadata in a registry. Aliases are
+// other names the object can be looked up by, for example names it had before
+// being renamed, and are added to by values implementing Aliaser.
 type Object struct {
-	Value   interface{}
-	Name    string
-	Fields  map[string]*Field
-	Enabled bool
-	PkgPath string
+	Value    interface{}
+	Name     string
+	Aliases  []string
+	Fields   map[string]*Field
+	Enabled  bool
+	PkgPath  string
+	Priority int
 
+	factory      reflect.Value
+	eager        bool
+	unresolved   error
+	constructing bool
+	decorations  map[decoration]reflect.Value
+	source       *Object
 	reflectType  reflect.Type
 	reflectValue reflect.Value
 }
 
+// snapshot returns a copy of a registered object's metadata that can be read
+// without holding the registry lock. Field metadata is copied as well, but
+// fields still refer to the same object value.
+func (o *Object) snapshot() *Object {
+	c := *o
+	c.source = o.registered()
+	c.Fields = make(map[string]*Field, len(o.Fields))
+	for name, f := range o.Fields {
+		fc := *f
+		fc.Object = &c
+		c.Fields[name] = &fc
+	}
+	return &c
+}
+
+// registered returns the object in the registry an object is a snapshot of,
+// or the object itself if it is not a snapshot.
+func (o *Object) registered() *Object {
+	if o.source != nil {
+		return o.source
+	}
+	return o
+}
+
 // New creates a new Object by value and name
 func New(v interface{}, name string) *Object {
 	return &Object{Value: v, Name: name}
@@ -38,7 +114,8 @@ func (o *Object) FQN() string {
 
 // Assign will set a named field of the object value if it has not already
 // been assigned. It will not assign to fields marked as extension points.
-// It will return true if the assignment is successful.
+// It will return true if the assignment is successful. Use Registry.Assign for
+// objects that have been registered.
 func (o *Object) Assign(field string, obj *Object) bool {
 	f, ok := o.Fields[field]
 	if !ok {
@@ -51,99 +128,450 @@ func (o *Object) Assign(field string, obj *Object) bool {
 	// don't assign to extpoints because as slices they are handled differently
 	if !f.Extpoint && obj.reflectType.AssignableTo(f.reflectValue.Type()) {
 		f.reflectValue.Set(reflect.ValueOf(obj.Value))
+		f.assigned = obj
 		return true
 	}
 	return false
 }
 
-// Field represents metadata of a field in an Object value's struct.
+// fieldNames returns the names of the object's tagged fields in a stable order.
+func (o *Object) fieldNames() []string {
+	var names []string
+	for name := range o.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Field represents metadata of a field in an Object value's struct. Tag is
+// the kind of com tag without any options.
 type Field struct {
 	Object   *Object
 	Name     string
 	Config   bool
 	Extpoint bool
+	Required bool
 	Tag      string
 
+	options      map[string]string
+	order        []string
+	assigned     *Object
 	reflectValue reflect.Value
 }
 
+// clear resets a field assigned by the registry back to its zero value. Fields
+// that were set some other way are left alone.
+func (f *Field) clear() {
+	if f.assigned == nil {
+		return
+	}
+	if f.assigned.is(f.reflectValue) {
+		f.reflectValue.Set(reflect.Zero(f.reflectValue.Type()))
+	}
+	f.assigned = nil
+}
+
+// parseTag splits a com struct tag into its kind and comma separated options.
+// Options may be flags like "required" or key values like "name=postgres".
+func parseTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	options := make(map[string]string)
+	for _, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) == 2 {
+			options[kv[0]] = kv[1]
+		} else {
+			options[kv[0]] = ""
+		}
+	}
+	return strings.TrimSpace(parts[0]), options
+}
+
 // Registry is a container for objects.
+//
+// A Registry is safe for concurrent use. Methods that change the registry or
+// populate fields hold a write lock for the whole operation, so wiring is
+// published all at once and other goroutines using the registry never see it
+// half done. Methods that only read the registry share a read lock. Objects
+// returned by the registry, including through Graph and events, are
+// snapshots of their metadata, such as Enabled, at the time they were
+// returned. Use registry methods like SetEnabled and Assign to change them
+// rather than modifying the snapshots.
+//
+// Fields of object values are set while the registry is locked, but reading
+// them from object values is up to the application to synchronize, typically
+// by only changing the registry before objects are in use or by holding Lock
+// while reading them.
 type Registry struct {
-	sync.Mutex
-	objects  []*Object
-	disabled map[string]bool
+	mu sync.RWMutex
+
+	// StartTimeout limits how long each object's Start hook may take. Zero
+	// means no limit beyond the context passed to Start.
+	StartTimeout time.Duration
+
+	// StopTimeout limits how long each object's Stop hook may take. Zero
+	// means no limit beyond the context passed to Stop.
+	StopTimeout time.Duration
+
+	// RequireAll makes every singleton and config field required unless it
+	// has the optional tag option.
+	RequireAll bool
+
+	objects       []*Object
+	disabled      map[string]bool
+	started       []*Object
+	pending       []Event
+	subscriptions []subscription
+	lastID        int
+	parent        *Registry
+	inherited     map[*Object]*Object
+	constructed   bool
+}
+
+// Lock acquires the registry's write lock, which applications can use to
+// keep the registry from changing while they read fields of object values.
+// Registry methods take the lock themselves, so they must not be called
+// until Unlock.
+func (r *Registry) Lock() {
+	r.mu.Lock()
 }
 
-// Register adds objects to the registry.
+// Unlock releases the lock acquired by Lock.
+func (r *Registry) Unlock() {
+	r.mu.Unlock()
+}
+
+// Register adds objects to the registry. If any objects have misused tags,
+// the FieldErrors for all of them are returned together in Errors and none of
+// the objects are added. Likewise, if populating fields with the new objects
+// fails, for example because a singleton field now matches more than one
+// object, the errors are returned and the registry is restored to how it was
+// before the call.
 func (r *Registry) Register(objects ...*Object) error {
-	r.Lock()
-	defer r.Unlock()
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
 	r.initDisabled()
+	var prepared []*Object
+	var errs Errors
 	for _, o := range objects {
-		// set up type and value reflection
-		o.reflectType = reflect.TypeOf(o.Value)
-		o.reflectValue = reflect.ValueOf(o.Value)
-
 		// if not a struct, ignore
-		if !isStructPtr(o.reflectType) {
+		if !isStructPtr(reflect.TypeOf(o.Value)) {
 			continue
 		}
+		if err := r.prepare(o); err != nil {
+			errs = errs.add(err)
+			continue
+		}
+		prepared = append(prepared, o)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	saved, pending := r.snapshot(), len(r.pending)
+	for _, o := range prepared {
+		// enable unless already marked as disabled
+		o.Enabled = !r.isDisabled(o.FQN())
 
-		// collect tagged fields
-		o.Fields = make(map[string]*Field)
-		for i := 0; i < o.reflectValue.Elem().NumField(); i++ {
-			field := o.reflectValue.Elem().Field(i)
-			fieldName := o.reflectType.Elem().Field(i).Name
-			fieldTag, ok := o.reflectType.Elem().Field(i).Tag.Lookup("com")
-			if ok && field.CanSet() {
-				o.Fields[fieldName] = &Field{
-					Name:         fieldName,
-					Config:       fieldTag == TagConfig,
-					Extpoint:     fieldTag == TagExtpoint,
-					Tag:          fieldTag,
-					reflectValue: field,
-				}
+		// append object to registry list of objects
+		r.objects = append(r.objects, o)
+		r.emit(EventRegistered, o, "")
+	}
+
+	// re-populate registered objects
+	if err := r.reload(); err != nil {
+		r.restore(saved)
+		r.pending = r.pending[:pending]
+		return err
+	}
+	return nil
+}
+
+// prepare sets up reflection, tagged fields, and naming of an object value
+// before it is added to the registry.
+func (r *Registry) prepare(o *Object) error {
+	// set up type and value reflection
+	o.reflectType = reflect.TypeOf(o.Value)
+	o.reflectValue = reflect.ValueOf(o.Value)
+	if err := o.setNames(); err != nil {
+		return err
+	}
+	return o.collectFields()
+}
+
+// collectFields sets up metadata for fields of the object value with com tags.
+// Any misused tags are returned as FieldErrors together in Errors.
+func (o *Object) collectFields() error {
+	o.Fields = make(map[string]*Field)
+	return o.collectStructFields(o.reflectValue.Elem(), "", map[reflect.Type]bool{}).errOrNil()
+}
+
+// collectStructFields adds tagged fields of a struct value to the object. It
+// walks into embedded structs and fields tagged nested, naming their fields
+// qualified by the path to them, for example "Base.Log".
+func (o *Object) collectStructFields(v reflect.Value, prefix string, path map[reflect.Type]bool) Errors {
+	var errs Errors
+	path[v.Type()] = true
+	defer delete(path, v.Type())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldName := prefix + v.Type().Field(i).Name
+		fieldTag, ok := v.Type().Field(i).Tag.Lookup("com")
+		kind, options := parseTag(fieldTag)
+		if ok {
+			problems := tagProblems(kind, options, field)
+			for _, problem := range problems {
+				errs = append(errs, &FieldError{Object: o.FQN(), Field: fieldName, Problem: problem})
+			}
+			if len(problems) > 0 {
+				continue
+			}
+		}
+		if (!ok && v.Type().Field(i).Anonymous) || (ok && kind == TagNested) {
+			if nested, ok := structValue(field); ok && !path[nested.Type()] {
+				errs = append(errs, o.collectStructFields(nested, fieldName+".", path)...)
+			}
+			continue
+		}
+		if ok {
+			_, required := options[OptRequired]
+			o.Fields[fieldName] = &Field{
+				Object:       o,
+				Name:         fieldName,
+				Config:       kind == TagConfig,
+				Extpoint:     kind == TagExtpoint,
+				Required:     required,
+				Tag:          kind,
+				options:      options,
+				reflectValue: field,
 			}
 		}
+	}
+	return errs
+}
 
-		// set normalized package path. if the package is "com" we assume it
-		// contains the component for its parent, so we strip it off.
-		o.PkgPath = strings.TrimSuffix(o.reflectType.Elem().PkgPath(), "/com")
+// structValue returns the struct held by a field directly or by pointer. A nil
+// pointer is set to a new struct if the struct type has com tags.
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	switch {
+	case v.Kind() == reflect.Struct:
+		return v, true
+	case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct:
+		if v.IsNil() {
+			if !v.CanSet() || !hasTags(v.Type().Elem(), map[reflect.Type]bool{}) {
+				return reflect.Value{}, false
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return v.Elem(), true
+	}
+	return reflect.Value{}, false
+}
 
-		// the default name is set by the name of the struct type
-		if o.Name == "" {
-			o.Name = o.reflectType.Elem().Name()
+// hasTags returns true if a struct type or any struct it embeds or nests has
+// fields with com tags.
+func hasTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("com")
+		kind, _ := parseTag(tag)
+		if ok && kind != TagNested {
+			return true
 		}
+		if !f.Anonymous && !ok {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasTags(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// setNames sets the package path and default name of the object from its type.
+func (o *Object) setNames() error {
+	// set normalized package path. if the package is "com" we assume it
+	// contains the component for its parent, so we strip it off.
+	o.PkgPath = strings.TrimSuffix(o.reflectType.Elem().PkgPath(), "/com")
 
-		// error if the object has no package path
-		if o.Name == "" && o.reflectType.Elem().PkgPath() == "" {
-			return errors.New("unable to register object without name when it has no package path")
+	// the default name is set by the name of the struct type
+	if o.Name == "" {
+		o.Name = o.reflectType.Elem().Name()
+	}
+
+	// error if the object has no package path
+	if o.Name == "" && o.reflectType.Elem().PkgPath() == "" {
+		return errors.New("unable to register object without name when it has no package path")
+	}
+	o.addAliases()
+	return nil
+}
+
+// addAliases adds any aliases declared by the object value.
+func (o *Object) addAliases() {
+	aliaser, ok := o.Value.(Aliaser)
+	if !ok {
+		return
+	}
+	for _, alias := range aliaser.Aliases() {
+		if !o.aliased(alias) {
+			o.Aliases = append(o.Aliases, alias)
 		}
+	}
+}
 
-		// enable unless already marked as disabled
-		_, disabled := r.disabled[o.FQN()]
-		o.Enabled = !disabled
+// aliased returns true if the name matches one of the object's aliases, as
+// is or qualified with its package path, rather than its Name or FQN.
+func (o *Object) aliased(name string) bool {
+	name = strings.ToLower(name)
+	if name == o.FQN() || name == strings.ToLower(o.Name) {
+		return false
+	}
+	for _, alias := range o.Aliases {
+		alias = strings.ToLower(alias)
+		if name == alias || name == strings.ToLower(o.PkgPath)+"#"+alias {
+			return true
+		}
+	}
+	return false
+}
 
-		// append object to registry list of objects
-		r.objects = append(r.objects, o)
+// Unregister removes an object from the registry by FQN. Any fields of other
+// objects it was assigned to are cleared and re-populated from the remaining
+// objects.
+func (r *Registry) Unregister(fqn string) error {
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	i := r.index(fqn)
+	if i < 0 {
+		return ErrNotFound
 	}
+	o := r.objects[i]
+	r.objects = append(r.objects[:i], r.objects[i+1:]...)
+	r.emit(EventUnregistered, o, "")
+	return r.reload()
+}
 
-	// re-populate registered objects
+// Replace swaps the value of an object in the registry by FQN, keeping its
+// FQN, aliases, priority, and enabled state, even if the new value is of a
+// type from another package. Any fields of other objects the previous value was
+// assigned to are cleared and re-populated, and config fields are assigned the
+// new value if possible.
+func (r *Registry) Replace(fqn string, v interface{}) error {
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	i := r.index(fqn)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if !isStructPtr(reflect.TypeOf(v)) {
+		return errors.New("unable to replace object with value that is not a struct pointer")
+	}
+	old := r.objects[i]
+	aliases := append([]string{}, old.Aliases...)
+	o := &Object{Value: v, Name: old.Name, Aliases: aliases, Priority: old.Priority}
+	if err := r.prepare(o); err != nil {
+		return err
+	}
+	o.PkgPath = old.PkgPath
+	o.Enabled = old.Enabled
+	r.objects[i] = o
+	r.emit(EventUnregistered, old, "")
+	r.emit(EventRegistered, o, "")
+	for _, existing := range r.objects {
+		for name, f := range existing.Fields {
+			if f.assigned == old {
+				f.clear()
+				if f.Config {
+					existing.Assign(name, o)
+				}
+			}
+		}
+	}
 	return r.reload()
 }
 
+// index returns the position of an object by FQN or -1 if not registered.
+func (r *Registry) index(fqn string) int {
+	fqn = strings.ToLower(fqn)
+	for i, o := range r.objects {
+		if o.FQN() == fqn {
+			return i
+		}
+	}
+	return -1
+}
+
 // Lookup will attempt to find an object in the registry...
 // 1. if it matches the object FQN exactly
 // 2. if it matches a single object Name
-// 3. if it matches a single object by package path suffix
+// 3. if it matches a single object alias, or alias qualified by package path
+// 4. if it matches a single object by package path suffix
+//
+// If the object was registered with a factory, has not been constructed, and
+// is enabled, it is constructed and the registry is reloaded before it is
+// returned. The object returned is a snapshot. EventDeprecatedName is emitted
+// if the object was found by an alias.
 func (r *Registry) Lookup(name string) (*Object, error) {
+	r.mu.RLock()
+	obj, err := r.lookup(name)
+	if err != nil || !r.constructable(obj) && !obj.aliased(name) {
+		defer r.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		return obj.snapshot(), nil
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	obj, err = r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if obj.aliased(name) {
+		r.pending = append(r.pending, Event{Type: EventDeprecatedName, Object: obj.snapshot(), Name: name})
+	}
+	if !r.constructable(obj) {
+		return obj.snapshot(), nil
+	}
+	if err := r.ensure(obj, r.scope()); err != nil {
+		return nil, err
+	}
+	return obj.snapshot(), r.reload()
+}
+
+// constructable returns true if an object found by lookup is lazy and enabled
+// in this registry, so Lookup should construct it.
+func (r *Registry) constructable(obj *Object) bool {
+	return obj.lazy() && !r.isDisabled(obj.FQN())
+}
+
+func (r *Registry) lookup(name string) (*Object, error) {
 	// TODO: allow to choose to ignore disabled
 	// TODO: match suffix for full FQN? (pkgpath+name)
 
 	// all matching is done case insensitive
 	name = strings.ToLower(name)
 	var matches []*Object
-	for _, obj := range r.Objects() {
+	for _, obj := range r.objects {
 		// first match any exact FQN
 		if obj.FQN() == name {
 			return obj, nil
@@ -159,11 +587,23 @@ func (r *Registry) Lookup(name string) (*Object, error) {
 	}
 	// if more than one, error
 	if len(matches) > 1 {
-		return nil, errors.New("ambiguous name for lookup")
+		return nil, ErrAmbiguous
+	}
+	// then match aliases the same way as names
+	for _, obj := range r.objects {
+		if obj.aliased(name) {
+			matches = append(matches, obj)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return nil, ErrAmbiguous
 	}
 	// now attempt suffix matches
 	matches = matches[:0]
-	for _, obj := range r.Objects() {
+	for _, obj := range r.objects {
 		if strings.HasSuffix(strings.ToLower(obj.PkgPath), name) {
 			matches = append(matches, obj)
 		}
@@ -172,19 +612,41 @@ func (r *Registry) Lookup(name string) (*Object, error) {
 		return matches[0], nil
 	}
 	if len(matches) > 1 {
-		return nil, errors.New("ambiguous name for lookup")
+		return nil, ErrAmbiguous
+	}
+	// finally fall back to the parent registry
+	if r.parent != nil {
+		r.parent.mu.RLock()
+		defer r.parent.mu.RUnlock()
+		obj, err := r.parent.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		return obj.snapshot(), nil
 	}
-	return nil, errors.New("object not found")
+	return nil, ErrNotFound
 }
 
 // SetEnabled will set whether an object is enabled.
 func (r *Registry) SetEnabled(fqn string, enabled bool) {
-	r.Lock()
-	defer r.Unlock()
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	r.setEnabled(fqn, enabled)
+}
+
+func (r *Registry) setEnabled(fqn string, enabled bool) {
 	r.initDisabled()
 	r.disabled[fqn] = !enabled
 	for _, o := range r.objects {
 		if o.FQN() == fqn {
+			if o.Enabled != enabled {
+				if enabled {
+					r.emit(EventEnabled, o, "")
+				} else {
+					r.emit(EventDisabled, o, "")
+				}
+			}
 			o.Enabled = enabled
 			break
 		}
@@ -197,32 +659,67 @@ func (r *Registry) initDisabled() {
 	}
 }
 
-// Enabled returns all enabled objects.
+// Enabled returns snapshots of all enabled objects.
 func (r *Registry) Enabled() []*Object {
-	r.Lock()
-	defer r.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var objects []*Object
 	for _, o := range r.objects {
 		if o.Enabled {
-			objects = append(objects, o)
+			objects = append(objects, o.snapshot())
 		}
 	}
 	return objects
 }
 
-// Objects returns all registered objects.
+// Objects returns snapshots of all registered objects.
 func (r *Registry) Objects() []*Object {
-	r.Lock()
-	defer r.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var objects []*Object
 	for _, o := range r.objects {
-		objects = append(objects, o)
+		objects = append(objects, o.snapshot())
 	}
 	return objects
 }
 
+// Assign sets a named field of a registered object to another object using
+// Object.Assign while holding the registry lock. Either object can be a
+// snapshot returned by the registry.
+func (r *Registry) Assign(obj *Object, field string, target *Object) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return obj.registered().Assign(field, target.registered())
+}
+
+// Unassign clears a named field of a registered object that was assigned an
+// object by the registry or with Assign, so it can be assigned again. It
+// returns true if the field was cleared.
+func (r *Registry) Unassign(obj *Object, field string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := obj.registered().Fields[field]
+	if !ok || f.assigned == nil {
+		return false
+	}
+	f.clear()
+	return true
+}
+
+// SetOrder overrides the order of an extension point field of a registered
+// object using Object.SetOrder while holding the registry lock. The object
+// can be a snapshot returned by the registry.
+func (r *Registry) SetOrder(obj *Object, field string, names []string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return obj.registered().SetOrder(field, names)
+}
+
 func (r *Registry) ValueTo(rv reflect.Value) {
 	for _, obj := range r.Objects() {
+		if obj.Value == nil {
+			continue
+		}
 		robj := reflect.ValueOf(obj.Value)
 		if rv.Elem().Type().Kind() == reflect.Struct {
 			if robj.Elem().Type().AssignableTo(rv.Elem().Type()) {
@@ -239,57 +736,333 @@ func (r *Registry) ValueTo(rv reflect.Value) {
 
 }
 
+// resolve returns the enabled objects assignable to a type, constructing any
+// lazy objects. As with singletons, objects of this registry are preferred
+// over inherited ones unless all is set, in which case they are sorted as
+// extension points. Objects that fail to construct are left out and their
+// errors returned together.
+func (r *Registry) resolve(t reflect.Type, all bool) ([]*Object, error) {
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	r.constructed = false
+	s := r.scope()
+	var objects []*Object
+	var errs Errors
+	for _, obj := range s.assignable(t, !all) {
+		if err := r.ensure(obj, s); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	if r.constructed {
+		if err := r.reload(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if all {
+		r.sortExtensions(nil, objects)
+	}
+	return objects, errs.errOrNil()
+}
+
 // Reload will go over all objects in the registry and attempt to populate
-// fields with com struct tags with other objects in the registry.
+// fields with com struct tags with other objects in the registry. Afterwards,
+// any required fields of enabled objects left unassigned are returned as
+// FieldErrors together in Errors.
+//
+// Reload is transactional. If it returns an error, the registry is restored
+// to how it was before the call and no events are emitted for it.
 func (r *Registry) Reload() error {
-	r.Lock()
-	defer r.Unlock()
-	return r.reload()
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	saved, pending := r.snapshot(), len(r.pending)
+	err := r.reload()
+	if err == nil {
+		err = r.validate()
+	}
+	if err != nil {
+		r.restore(saved)
+		r.pending = r.pending[:pending]
+	}
+	return err
 }
 
-func (r *Registry) reload() error {
+// required returns true if a field must be assigned, either because of its
+// tag options or because the registry requires all singleton and config fields.
+func (r *Registry) required(f *Field) bool {
+	if f.Extpoint {
+		return false
+	}
+	_, optional := f.options[OptOptional]
+	return f.Required || r.RequireAll && !optional
+}
+
+// validate checks that all required fields of enabled objects are assigned
+// and that their constructors have been called.
+// It is not part of reload since Register reloads after each registration,
+// when objects that will satisfy required fields may not be registered yet.
+func (r *Registry) validate() error {
+	var errs Errors
 	for _, o := range r.objects {
-		if err := r.populateSingletons(o); err != nil {
+		if !o.Enabled {
+			continue
+		}
+		if o.eager && o.lazy() {
+			errs = append(errs, o.unresolved)
+			continue
+		}
+		for _, name := range o.fieldNames() {
+			f := o.Fields[name]
+			if !r.required(f) {
+				continue
+			}
+			if isNilOrZero(f.reflectValue, f.reflectValue.Type()) {
+				errs = append(errs, &FieldError{
+					Object:  o.FQN(),
+					Field:   name,
+					Problem: fmt.Sprintf("required %s field not assigned", f.Tag),
+				})
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+func (r *Registry) reload() error {
+	before := r.wiring()
+	err := r.populate()
+	r.emitRewired(before)
+	if err == nil {
+		r.emit(EventReloaded, nil, "")
+	}
+	return err
+}
+
+// populate assigns fields of all objects. It is repeated if any lazy objects
+// were constructed so their fields are populated as well. Errors for all
+// objects are returned together in Errors.
+func (r *Registry) populate() error {
+	for {
+		r.constructed = false
+		s := r.scope()
+		r.clearStale(s)
+		if err := r.constructEager(s); err != nil {
 			return err
 		}
-		if err := r.populateExtpoints(o); err != nil {
+		if err := r.ensureDecorators(s); err != nil {
 			return err
 		}
+		var errs Errors
+		for _, o := range r.objects {
+			errs = errs.add(r.populateSingletons(o, s))
+			errs = errs.add(r.populateExtpoints(o, s))
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		if !r.constructed {
+			return nil
+		}
+	}
+}
+
+// clearStale clears fields assigned to objects that have since been disabled
+// or removed from the registry so they can be populated again.
+func (r *Registry) clearStale(s *scope) {
+	for _, o := range r.objects {
+		for _, f := range o.Fields {
+			if f.assigned != nil && !s.enabled[f.assigned] {
+				f.clear()
+			}
+		}
 	}
-	return nil
 }
 
-func (r *Registry) populateSingletons(o *Object) error {
-	for k, f := range o.Fields {
+func (r *Registry) populateSingletons(o *Object, s *scope) error {
+	var errs Errors
+	for _, name := range o.fieldNames() {
+		f := o.Fields[name]
 		if f.Config || f.Extpoint {
 			continue
 		}
-		for _, existing := range r.objects {
-			if existing.Enabled && existing.reflectType.AssignableTo(f.reflectValue.Type()) {
-				o.Assign(k, existing)
+		// fields already assigned by the registry are decorated again in
+		// case decorators changed, but fields set some other way are skipped
+		existing := f.assigned
+		if isNilOrZero(f.reflectValue, f.reflectValue.Type()) {
+			var err error
+			if existing, err = r.resolveSingleton(f, s); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		} else if existing == nil || !existing.is(f.reflectValue) {
+			continue
+		} else if !qualified(f) {
+			// objects registered since may make the field ambiguous
+			if _, err := r.singletonCandidate(f, s); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if existing == nil {
+			continue
+		}
+		v, err := r.decorate(existing, f.reflectValue.Type(), s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.reflectValue.Set(v)
+		f.assigned = existing
+	}
+	return errs.errOrNil()
+}
+
+// resolveSingleton finds the enabled object to assign to a singleton field.
+// If the field is qualified with the name or fqn option, only that object is
+// considered. Otherwise it must be the only enabled object assignable to the
+// field, preferring objects of this registry over inherited ones. It returns
+// nil without error if no object is found, since it may not be registered yet.
+func (r *Registry) resolveSingleton(f *Field, s *scope) (*Object, error) {
+	fieldErr := func(problem string) error {
+		return &FieldError{Object: f.Object.FQN(), Field: f.Name, Problem: problem}
+	}
+	fieldType := f.reflectValue.Type()
+	var match *Object
+	if name, ok := f.options[OptName]; ok {
+		obj, err := r.lookup(name)
+		if err == ErrAmbiguous {
+			return nil, fieldErr(fmt.Sprintf("ambiguous singleton name %q", name))
+		}
+		if obj != nil {
+			match = s.object(obj)
+		}
+	} else if fqn, ok := f.options[OptFQN]; ok {
+		for _, existing := range s.objects {
+			if existing.FQN() == strings.ToLower(fqn) {
+				match = existing
 				break
 			}
 		}
+	} else {
+		var err error
+		if match, err = r.singletonCandidate(f, s); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	if match == nil || !s.enabled[match] {
+		return nil, nil
+	}
+	if !match.reflectType.AssignableTo(fieldType) {
+		return nil, fieldErr(fmt.Sprintf("%s is not assignable to %s", match.FQN(), fieldType))
+	}
+	// construct lazy objects unless their factory can't be called yet
+	err := r.ensure(match, s)
+	if _, unresolved := err.(*unresolvedError); unresolved {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// qualified returns true if a singleton field names the object to assign with
+// the name or fqn option.
+func qualified(f *Field) bool {
+	_, name := f.options[OptName]
+	_, fqn := f.options[OptFQN]
+	return name || fqn
+}
+
+// singletonCandidate returns the only enabled object assignable to an
+// unqualified singleton field, preferring objects of this registry over
+// inherited ones, or nil if there is none. A FieldError is returned if there
+// is more than one.
+func (r *Registry) singletonCandidate(f *Field, s *scope) (*Object, error) {
+	candidates := s.assignable(f.reflectValue.Type(), true)
+	if len(candidates) > 1 {
+		var names []string
+		for _, c := range candidates {
+			names = append(names, c.FQN())
+		}
+		return nil, &FieldError{
+			Object:  f.Object.FQN(),
+			Field:   f.Name,
+			Problem: fmt.Sprintf("ambiguous singleton matches %s; qualify with name or fqn option", strings.Join(names, ", ")),
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	return candidates[0], nil
 }
 
-func (r *Registry) populateExtpoints(o *Object) error {
-	for _, f := range o.Fields {
+func (r *Registry) populateExtpoints(o *Object, s *scope) error {
+	var errs Errors
+	for _, name := range o.fieldNames() {
+		f := o.Fields[name]
 		if !f.Extpoint {
 			continue
 		}
-		var objects []reflect.Value
-		for _, existing := range r.objects {
-			if existing.Enabled && existing.reflectType.AssignableTo(f.reflectValue.Type().Elem()) {
-				objects = append(objects, existing.reflectValue)
+		elem := f.reflectValue.Type().Elem()
+		objects, err := r.ensureAll(s.assignable(elem, false), s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if f.reflectValue.Kind() != reflect.Map {
+			r.sortExtensions(f.order, objects)
+		}
+		values, err := r.decorateAll(objects, elem, s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if f.reflectValue.Kind() == reflect.Map {
+			if err := populateExtpointMap(f, objects, values); err != nil {
+				errs = append(errs, err)
 			}
+			continue
 		}
 		f.reflectValue.Set(reflect.MakeSlice(f.reflectValue.Type(), 0, len(objects)))
-		for _, obj := range objects {
-			f.reflectValue.Set(reflect.Append(f.reflectValue, obj))
+		for _, v := range values {
+			f.reflectValue.Set(reflect.Append(f.reflectValue, v))
+		}
+	}
+	return errs.errOrNil()
+}
+
+// populateExtpointMap sets a map extension point field to the values of
+// objects keyed by their Name, or by their FQN if the field has the key=fqn
+// option.
+func populateExtpointMap(f *Field, objects []*Object, values []reflect.Value) error {
+	if f.reflectValue.Type().Key().Kind() != reflect.String {
+		return &FieldError{
+			Object:  f.Object.FQN(),
+			Field:   f.Name,
+			Problem: "map extpoint must have string keys",
+		}
+	}
+	m := reflect.MakeMap(f.reflectValue.Type())
+	for i, obj := range objects {
+		key := obj.Name
+		if f.options[OptKey] == "fqn" {
+			key = obj.FQN()
+		}
+		k := reflect.ValueOf(key).Convert(f.reflectValue.Type().Key())
+		if m.MapIndex(k).IsValid() {
+			return &FieldError{
+				Object:  f.Object.FQN(),
+				Field:   f.Name,
+				Problem: fmt.Sprintf("duplicate extpoint key %q", key),
+			}
 		}
+		m.SetMapIndex(k, values[i])
 	}
+	f.reflectValue.Set(m)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package objects
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -45,6 +46,35 @@ func TestLookup(t *testing.T) {
 	}
 }
 
+type Renamed struct{}
+
+func (r *Renamed) Aliases() []string {
+	return []string{"Legacy"}
+}
+
+func TestLookupAlias(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Object{Value: &Renamed{}, Aliases: []string{"Old"}}); err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	if _, err := r.Lookup("Renamed"); err != nil || len(events) != 0 {
+		t.Fatalf("got %v and %d events; want lookup by name without events", err, len(events))
+	}
+	for _, name := range []string{"old", "Legacy", "github.com/gliderlabs/com/objects#old"} {
+		obj, err := r.Lookup(name)
+		if err != nil || obj.Name != "Renamed" {
+			t.Fatalf("lookup by alias %q failed: %v", name, err)
+		}
+	}
+	if len(events) != 3 || events[0].Type != EventDeprecatedName || events[0].Name != "old" {
+		t.Fatalf("got %#v; want deprecation events", events)
+	}
+}
+
 func TestStructSingleton(t *testing.T) {
 	r := &Registry{}
 	v1 := &Foo{t.Name()}
@@ -136,3 +166,308 @@ func TestConfigFieldLeftUnassigned(t *testing.T) {
 		t.Fatal("config field not left unassigned")
 	}
 }
+
+func TestRequiredFieldUnassigned(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo     `com:"singleton,required"`
+		B Stringer `com:"config,required"`
+		C *Foo     `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	errs, ok := r.Reload().(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("got %#v; want 2 errors", errs)
+	}
+	if fe, ok := errs[0].(*FieldError); !ok || fe.Field != "A" {
+		t.Fatalf("got %#v; want field error for A", errs[0])
+	}
+}
+
+func TestRequireAll(t *testing.T) {
+	r := &Registry{RequireAll: true}
+	var v struct {
+		A *Foo `com:"singleton"`
+		B *Foo `com:"singleton,optional"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if errs, ok := r.Reload().(Errors); !ok || len(errs) != 1 {
+		t.Fatalf("got %#v; want 1 error", errs)
+	}
+	if err := r.Register(&Object{Value: &Foo{t.Name()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAmbiguousSingleton(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A Stringer `com:"singleton"`
+	}
+	err := r.Register(&Object{Value: &Foo{"foo1"}, Name: "foo1"},
+		&Object{Value: &Foo{"foo2"}, Name: "foo2"}, &Object{Value: &v, Name: "v"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got %#v; want ambiguous field error", err)
+	}
+	if _, ok := errs[0].(*FieldError); !ok {
+		t.Fatalf("got %#v; want ambiguous field error", errs[0])
+	}
+}
+
+func TestAmbiguousAfterAssigned(t *testing.T) {
+	r := &Registry{}
+	foo1 := &Foo{"foo1"}
+	var v struct {
+		A Stringer `com:"singleton"`
+	}
+	err := r.Register(&Object{Value: &v, Name: "v"}, &Object{Value: foo1, Name: "foo1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Register(&Object{Value: &Foo{"foo2"}, Name: "foo2"})
+	if errs, ok := err.(Errors); !ok || len(errs) != 1 {
+		t.Fatalf("got %#v; want ambiguous field error", err)
+	}
+	if len(r.Objects()) != 2 || v.A != foo1 {
+		t.Fatal("registry not restored after failed register")
+	}
+}
+
+func TestPopulateErrorsCollected(t *testing.T) {
+	r := &Registry{}
+	var v1, v2 struct {
+		A Stringer `com:"singleton"`
+	}
+	err := r.Register(&Object{Value: &Foo{"foo1"}, Name: "foo1"},
+		&Object{Value: &Foo{"foo2"}, Name: "foo2"},
+		&Object{Value: &v1, Name: "v1"}, &Object{Value: &v2, Name: "v2"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("got %#v; want errors for both objects", err)
+	}
+}
+
+func TestNamedSingleton(t *testing.T) {
+	r := &Registry{}
+	foo2 := &Foo{"foo2"}
+	var v struct {
+		A Stringer `com:"singleton,name=foo2"`
+		B Stringer `com:"singleton,fqn=github.com/gliderlabs/com/objects#foo2"`
+	}
+	err := r.Register(&Object{Value: &Foo{"foo1"}, Name: "foo1"},
+		&Object{Value: foo2, Name: "foo2"}, &Object{Value: &v, Name: "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != foo2 || v.B != foo2 {
+		t.Fatal("qualified singleton fields not set to named object")
+	}
+}
+
+func TestMapExtpoints(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A map[string]Stringer `com:"extpoint"`
+		B map[string]Stringer `com:"extpoint,key=fqn"`
+	}
+	err := r.Register(&Object{Value: &v, Name: "v"},
+		&Object{Value: &Foo{"ext1"}, Name: "ext1"},
+		&Object{Value: &Foo{"ext2"}, Name: "ext2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.A) != 2 || v.A["ext1"].String() != "ext1" {
+		t.Fatalf("got %#v; want extensions keyed by name", v.A)
+	}
+	if v.B["github.com/gliderlabs/com/objects#ext2"] == nil {
+		t.Fatalf("got %#v; want extensions keyed by fqn", v.B)
+	}
+	obj, _ := r.Lookup("ext1")
+	r.SetEnabled(obj.FQN(), false)
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.A["ext1"]; ok || len(v.A) != 1 {
+		t.Fatalf("got %#v; want disabled extension removed", v.A)
+	}
+}
+
+func TestUnregisterRewires(t *testing.T) {
+	r := &Registry{}
+	foo1 := &Foo{"foo1"}
+	var v struct {
+		A *Foo       `com:"singleton,name=foo1"`
+		B []Stringer `com:"extpoint"`
+	}
+	err := r.Register(&Object{Value: foo1, Name: "foo1"},
+		&Object{Value: &Foo{"foo2"}, Name: "foo2"}, &Object{Value: &v, Name: "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != foo1 || len(v.B) != 2 {
+		t.Fatal("fields not populated after register")
+	}
+	if err := r.Unregister("github.com/gliderlabs/com/objects#foo1"); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != nil || len(v.B) != 1 {
+		t.Fatal("fields not rewired after unregister")
+	}
+	if err := r.Unregister("github.com/gliderlabs/com/objects#foo1"); err != ErrNotFound {
+		t.Fatalf("got %#v; want ErrNotFound", err)
+	}
+}
+
+func TestReplaceRewires(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo     `com:"singleton"`
+		B Stringer `com:"config"`
+	}
+	obj := &Object{Value: &v, Name: "v"}
+	foo := &Object{Value: &Foo{"old"}, Name: "foo"}
+	if err := r.Register(foo, obj); err != nil {
+		t.Fatal(err)
+	}
+	obj.Assign("B", foo)
+	if err := r.Replace(foo.FQN(), &Foo{"new"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "new" || v.B.String() != "new" {
+		t.Fatal("fields not rewired to replacement")
+	}
+}
+
+func TestReplaceKeepsFQN(t *testing.T) {
+	r := &Registry{}
+	foo := &Object{Value: &Foo{"old"}, Name: "foo"}
+	if err := r.Register(foo); err != nil {
+		t.Fatal(err)
+	}
+	fqn := foo.FQN()
+	if err := r.Replace(fqn, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.Lookup(fqn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.FQN() != fqn {
+		t.Fatalf("got %q; want %q", obj.FQN(), fqn)
+	}
+}
+
+func TestDisableClearsAssigned(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo `com:"singleton"`
+	}
+	foo := &Object{Value: &Foo{t.Name()}}
+	if err := r.Register(foo, &Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	r.SetEnabled(foo.FQN(), false)
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != nil {
+		t.Fatal("field still assigned to disabled object")
+	}
+}
+
+type Base struct {
+	Foo *Foo `com:"singleton"`
+}
+
+type base struct {
+	Stringer Stringer `com:"singleton"`
+}
+
+type Embedding struct {
+	Base
+	*base
+	Nested struct {
+		Exts []Stringer `com:"extpoint"`
+	} `com:"nested"`
+	Ptr *Base `com:"nested"`
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	r := &Registry{}
+	foo := &Foo{t.Name()}
+	v := &Embedding{base: &base{}}
+	if err := r.Register(&Object{Value: foo}, &Object{Value: v}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Base.Foo != foo || v.base.Stringer != foo {
+		t.Fatal("embedded struct fields not populated")
+	}
+	if len(v.Nested.Exts) != 1 || v.Ptr == nil || v.Ptr.Foo != foo {
+		t.Fatal("nested struct fields not populated")
+	}
+	obj, _ := r.Lookup("Embedding")
+	if _, ok := obj.Fields["Base.Foo"]; !ok {
+		t.Fatal("embedded field not registered with qualified name")
+	}
+}
+
+func TestTagDiagnostics(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo     `com:"singelton"`
+		b *Foo     `com:"singleton"`
+		C Stringer `com:"extpoint"`
+		D *Foo     `com:"singleton,bogus"`
+		E Foo      `com:"config"`
+		F *Foo     `com:"singleton"`
+	}
+	err := r.Register(&Object{Value: &v, Name: "v"})
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("got %#v; want Errors", err)
+	}
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.(*FieldError).Field)
+	}
+	want := []string{"A", "b", "C", "D", "E"}
+	if !equalStrings(fields, want) {
+		t.Fatalf("got %#v; want errors for %#v", fields, want)
+	}
+	if len(r.Objects()) != 0 {
+		t.Fatal("object with misused tags was registered")
+	}
+}
+
+func TestRegisterAtomic(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Object{Value: &Foo{"foo"}, Name: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		A Stringer `com:"singleton"`
+	}
+	var bad1 struct {
+		A *Foo `com:"singelton"`
+	}
+	var bad2 struct {
+		B *Foo `com:"singleton,bogus"`
+	}
+	err := r.Register(&Object{Value: &v, Name: "v"},
+		&Object{Value: &bad1, Name: "bad1"}, &Object{Value: &bad2, Name: "bad2"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("got %#v; want Errors for both objects", err)
+	}
+	if len(r.Objects()) != 1 || v.A != nil {
+		t.Fatal("objects registered despite misused tags")
+	}
+}
@@ -0,0 +1,65 @@
+package objects
+
+import "sort"
+
+// Prioritizer is an interface objects can implement to declare their priority
+// in extension points. It is only used if the Object Priority is zero.
+type Prioritizer interface {
+	// Priority returns the priority of the object. Higher priority objects
+	// come first in extension points.
+	Priority() int
+}
+
+// priority returns the priority of the object, preferring the Object Priority
+// over the value implementing Prioritizer.
+func (o *Object) priority() int {
+	if o.Priority != 0 {
+		return o.Priority
+	}
+	if p, ok := o.Value.(Prioritizer); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// SetOrder overrides the order of objects in an extension point field with
+// a list of object names resolved by Lookup. Listed objects come first in
+// the order given, followed by the rest sorted as usual. The order is used
+// the next time the registry is reloaded. It returns false if the field is
+// not an extension point.
+func (o *Object) SetOrder(field string, names []string) bool {
+	f, ok := o.Fields[field]
+	if !ok || !f.Extpoint {
+		return false
+	}
+	f.order = names
+	return true
+}
+
+// sortExtensions sorts objects for an extension point. Objects named in the
+// order come first, then objects by descending priority, then by FQN so the
+// result doesn't depend on registration order.
+func (r *Registry) sortExtensions(order []string, objects []*Object) {
+	rank := make(map[*Object]int)
+	for i, name := range order {
+		if obj, err := r.lookup(name); err == nil {
+			if _, exists := rank[obj.registered()]; !exists {
+				rank[obj.registered()] = i
+			}
+		}
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		ri, iRanked := rank[objects[i].registered()]
+		rj, jRanked := rank[objects[j].registered()]
+		switch {
+		case iRanked && jRanked:
+			return ri < rj
+		case iRanked != jRanked:
+			return iRanked
+		case objects[i].priority() != objects[j].priority():
+			return objects[i].priority() > objects[j].priority()
+		default:
+			return objects[i].FQN() < objects[j].FQN()
+		}
+	})
+}
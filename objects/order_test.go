@@ -0,0 +1,64 @@
+package objects
+
+import "testing"
+
+type prioritized struct {
+	Foo
+	priority int
+}
+
+func (p *prioritized) Priority() int {
+	return p.priority
+}
+
+func extpointNames(exts []Stringer) []string {
+	var names []string
+	for _, e := range exts {
+		names = append(names, e.String())
+	}
+	return names
+}
+
+func TestExtpointPriorityOrder(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A []Stringer `com:"extpoint"`
+	}
+	err := r.Register(&Object{Value: &v, Name: "v"},
+		&Object{Value: &Foo{"c"}, Name: "c"},
+		&Object{Value: &prioritized{Foo{"b"}, 5}, Name: "b"},
+		&Object{Value: &Foo{"a"}, Name: "a"},
+		&Object{Value: &Foo{"d"}, Name: "d", Priority: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"d", "b", "a", "c"}
+	if got := extpointNames(v.A); !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestExtpointSetOrder(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A []Stringer `com:"extpoint"`
+	}
+	obj := &Object{Value: &v, Name: "v"}
+	err := r.Register(obj,
+		&Object{Value: &Foo{"a"}, Name: "a"},
+		&Object{Value: &Foo{"b"}, Name: "b"},
+		&Object{Value: &Foo{"c"}, Name: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !obj.SetOrder("A", []string{"c", "missing", "b"}) {
+		t.Fatal("order not set on extpoint field")
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c", "b", "a"}
+	if got := extpointNames(v.A); !equalStrings(got, want) {
+		t.Fatalf("got %#v; want %#v", got, want)
+	}
+}
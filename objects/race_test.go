@@ -0,0 +1,149 @@
+package objects
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentUse(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A []Stringer `com:"extpoint"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe := r.Subscribe(func(e Event) {
+		if e.Object != nil {
+			_ = e.Object.Enabled
+		}
+	})
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("foo%d", i)
+			if err := r.Register(&Object{Value: &Foo{name}, Name: name}); err != nil {
+				t.Error(err)
+				return
+			}
+			for j := 0; j < 20; j++ {
+				obj, err := r.Lookup(name)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				r.SetEnabled(obj.FQN(), j%2 == 0)
+				r.Reload()
+				for _, o := range r.Objects() {
+					_ = o.Enabled
+				}
+				r.Graph()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(r.Objects()) != 9 {
+		t.Fatalf("got %d objects; want 9", len(r.Objects()))
+	}
+}
+
+func TestSnapshotIsolated(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Object{Value: &Foo{t.Name()}, Name: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := r.Lookup("foo")
+	obj.Enabled = false
+	if again, _ := r.Lookup("foo"); !again.Enabled {
+		t.Fatal("changing a snapshot changed the registered object")
+	}
+}
+
+func TestConcurrentChildReload(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		parent := &Registry{}
+		if err := parent.RegisterFactory(func() *Foo { return &Foo{"foo"} }, "foo"); err != nil {
+			t.Fatal(err)
+		}
+		err := parent.Register(&Object{Value: &Prefixer{"p:", 0}, Name: "prefixer"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		child := parent.NewChild()
+		if err := child.Register(&Object{Value: &Foo{"bar"}, Name: "bar"}); err != nil {
+			t.Fatal(err)
+		}
+
+		var p struct {
+			A Stringer `com:"singleton"`
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := child.Reload(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := parent.Lookup("foo"); err != nil {
+				t.Error(err)
+			}
+			if err := parent.Register(&Object{Value: &p, Name: "p"}); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Wait()
+
+		var c struct {
+			A Stringer `com:"singleton,name=foo"`
+		}
+		if err := child.Register(&Object{Value: &c, Name: "c"}); err != nil {
+			t.Fatal(err)
+		}
+		if c.A == nil || c.A.String() != "p:foo" {
+			t.Fatalf("got %v; want %q", c.A, "p:foo")
+		}
+		decorated := c.A
+		if err := child.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		if c.A != decorated {
+			t.Fatal("inherited object decorated again on reload")
+		}
+	}
+}
+
+func TestLockBlocksChanges(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A Stringer `com:"singleton"`
+	}
+	if err := r.Register(&Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	var locker sync.Locker = r
+	locker.Lock()
+	done := make(chan error)
+	go func() {
+		done <- r.Register(&Object{Value: &Foo{"foo"}, Name: "foo"})
+	}()
+	if v.A != nil {
+		t.Fatal("field assigned while registry locked")
+	}
+	locker.Unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if v.A == nil {
+		t.Fatal("field not assigned after unlock")
+	}
+}
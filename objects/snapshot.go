@@ -0,0 +1,131 @@
+package objects
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Snapshot is the saved state of a registry, made with Registry.Snapshot and
+// brought back with Registry.Restore. It captures which objects are
+// registered, whether they are enabled, and the values of their tagged fields.
+type Snapshot struct {
+	registry *Registry
+	objects  []*Object
+	disabled map[string]bool
+	states   map[*Object]objectState
+}
+
+type objectState struct {
+	value        interface{}
+	reflectValue reflect.Value
+	enabled      bool
+	unresolved   error
+	fields       map[string]*Field
+	values       map[*Field]fieldState
+}
+
+type fieldState struct {
+	value    reflect.Value
+	assigned *Object
+	order    []string
+}
+
+// Snapshot saves the current state of the registry so it can be restored
+// later, for example to undo changes made while trying out new configuration.
+func (r *Registry) Snapshot() *Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.snapshot()
+}
+
+// Restore returns the registry to the state saved in a snapshot. Objects
+// registered since are removed, objects unregistered since are registered
+// again, and fields are set back to their saved values. Lazy objects
+// constructed since are left to be constructed again, though any side effects
+// of their factories are not undone. Events are emitted for the differences.
+func (r *Registry) Restore(s *Snapshot) error {
+	if s == nil || s.registry != r {
+		return errors.New("unable to restore snapshot of another registry")
+	}
+	r.mu.Lock()
+	defer r.dispatch()
+	defer r.mu.Unlock()
+	before := r.wiring()
+	current := make(map[*Object]bool)
+	for _, o := range r.objects {
+		current[o] = true
+	}
+	for _, o := range r.objects {
+		if _, saved := s.states[o]; !saved {
+			r.emit(EventUnregistered, o, "")
+		}
+	}
+	for _, o := range s.objects {
+		if enabled := s.states[o].enabled; current[o] && o.Enabled != enabled {
+			if enabled {
+				r.emit(EventEnabled, o, "")
+			} else {
+				r.emit(EventDisabled, o, "")
+			}
+		}
+	}
+	r.restore(s)
+	for _, o := range r.objects {
+		if !current[o] {
+			r.emit(EventRegistered, o, "")
+		}
+	}
+	r.emitRewired(before)
+	return nil
+}
+
+func (r *Registry) snapshot() *Snapshot {
+	s := &Snapshot{
+		registry: r,
+		objects:  append([]*Object{}, r.objects...),
+		disabled: make(map[string]bool, len(r.disabled)),
+		states:   make(map[*Object]objectState, len(r.objects)),
+	}
+	for fqn, disabled := range r.disabled {
+		s.disabled[fqn] = disabled
+	}
+	for _, o := range r.objects {
+		state := objectState{
+			value:        o.Value,
+			reflectValue: o.reflectValue,
+			enabled:      o.Enabled,
+			unresolved:   o.unresolved,
+			fields:       o.Fields,
+			values:       make(map[*Field]fieldState, len(o.Fields)),
+		}
+		for _, f := range o.Fields {
+			v := reflect.New(f.reflectValue.Type()).Elem()
+			v.Set(f.reflectValue)
+			state.values[f] = fieldState{value: v, assigned: f.assigned, order: f.order}
+		}
+		s.states[o] = state
+	}
+	return s
+}
+
+// restore sets the registry back to a snapshot without emitting events.
+func (r *Registry) restore(s *Snapshot) {
+	r.objects = append([]*Object{}, s.objects...)
+	r.disabled = make(map[string]bool, len(s.disabled))
+	for fqn, disabled := range s.disabled {
+		r.disabled[fqn] = disabled
+	}
+	for _, o := range r.objects {
+		state := s.states[o]
+		o.Value = state.value
+		o.reflectValue = state.reflectValue
+		o.Enabled = state.enabled
+		o.unresolved = state.unresolved
+		o.Fields = state.fields
+		for f, fs := range state.values {
+			f.reflectValue.Set(fs.value)
+			f.assigned = fs.assigned
+			f.order = fs.order
+		}
+	}
+}
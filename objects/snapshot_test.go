@@ -0,0 +1,65 @@
+package objects
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo       `com:"singleton"`
+		B []Stringer `com:"extpoint"`
+	}
+	foo := &Foo{"foo"}
+	if err := r.Register(&Object{Value: foo, Name: "foo"}, &Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	s := r.Snapshot()
+	obj, _ := r.Lookup("foo")
+	if err := r.Unregister(obj.FQN()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(&Object{Value: &Foo{"bar"}, Name: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.A == foo {
+		t.Fatal("field not rewired after unregister")
+	}
+	if err := r.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != foo || len(v.B) != 1 || v.B[0] != foo {
+		t.Fatal("fields not restored from snapshot")
+	}
+	if _, err := r.Lookup("bar"); err != ErrNotFound {
+		t.Fatalf("got %#v; want object registered after snapshot removed", err)
+	}
+	if err := (&Registry{}).Restore(s); err == nil {
+		t.Fatal("expected error restoring snapshot of another registry")
+	}
+}
+
+func TestReloadRollback(t *testing.T) {
+	r := &Registry{}
+	var v struct {
+		A *Foo       `com:"singleton,required"`
+		B []Stringer `com:"extpoint"`
+	}
+	foo := &Foo{"foo"}
+	if err := r.Register(&Object{Value: foo, Name: "foo"}, &Object{Value: &v, Name: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := r.Lookup("foo")
+	r.SetEnabled(obj.FQN(), false)
+	var events []Event
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected required field error")
+	}
+	if v.A != foo || len(v.B) != 1 {
+		t.Fatal("fields not restored after failed reload")
+	}
+	if len(events) != 0 {
+		t.Fatalf("got %#v; want no events from failed reload", events)
+	}
+}